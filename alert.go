@@ -0,0 +1,190 @@
+package hub
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// defaultAlertRate is the max number of alerts published per second.
+	defaultAlertRate = 100
+	// defaultAlertInterval is the interval used to aggregate the messages lost by one subscription.
+	defaultAlertInterval = time.Second
+)
+
+// lossCounter aggregates the messages lost by one subscription and publishes one alert per interval.
+type lossCounter struct {
+	h      *Hub
+	topics []string
+	sub    Subscriber
+	id     uint64
+	mu     sync.Mutex
+	missed int
+	waited time.Duration
+	first  time.Time
+	last   time.Time
+}
+
+// alerter limits the alerts published by the hub using a token bucket and counts the dropped alerts.
+type alerter struct {
+	dropped uint64
+	rate    float64
+	mu      sync.Mutex
+	tokens  float64
+	last    time.Time
+}
+
+// newAlerter returns an alerter allowing rate alerts per second, if rate <= 0 the alerts are not limited.
+func newAlerter(rate int) *alerter {
+	return &alerter{rate: float64(rate), tokens: float64(rate)}
+}
+
+// allow returns true if one alert can be published at the given time.
+func (a *alerter) allow(now time.Time) bool {
+	if a.rate <= 0 {
+		return true
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if !a.last.IsZero() && now.After(a.last) {
+		a.tokens += now.Sub(a.last).Seconds() * a.rate
+		if a.tokens > a.rate {
+			a.tokens = a.rate
+		}
+	}
+
+	a.last = now
+
+	if a.tokens < 1 {
+		return false
+	}
+
+	a.tokens--
+
+	return true
+}
+
+// drop counts one dropped alert.
+func (a *alerter) drop() {
+	atomic.AddUint64(&a.dropped, 1)
+}
+
+// AlertsDropped returns the number of alerts not published because they were rate limited or
+// because they were about an alert lost by some subscriber.
+func (h *Hub) AlertsDropped() uint64 {
+	return atomic.LoadUint64(&h.alerts.dropped)
+}
+
+// newLossCounter returns a lossCounter publishing the alerts for a subscription with the given topics.
+func (h *Hub) newLossCounter(topics []string) *lossCounter {
+	return &lossCounter{h: h, topics: topics}
+}
+
+// full counts one message lost because the subscription is full.
+func (l *lossCounter) full(msg Message) {
+	l.add(msg, ReasonFull, 0)
+}
+
+// evicted counts one message evicted from the subscription.
+func (l *lossCounter) evicted(msg Message) {
+	l.add(msg, ReasonEvicted, 0)
+}
+
+// timedOut counts one message lost by the subscription after waiting the given duration.
+func (l *lossCounter) timedOut(msg Message, waited time.Duration) {
+	l.add(msg, ReasonTimeout, waited)
+}
+
+// add counts one message lost by the subscription and send it to the dead letter.
+// Alerts lost by subscribers of the alert topic are only counted, alerting them could recurse without bound.
+func (l *lossCounter) add(msg Message, reason string, waited time.Duration) {
+	if msg.Name == l.h.alertTopic {
+		l.h.alerts.drop()
+		return
+	}
+
+	now := l.h.now()
+	l.h.deadLetter(DeadLetter{
+		Message:        msg,
+		Topics:         l.topics,
+		Subscriber:     l.sub,
+		SubscriptionID: l.id,
+		Reason:         reason,
+		Time:           now,
+	})
+
+	l.mu.Lock()
+	l.missed++
+	l.waited += waited
+	l.last = now
+
+	first := l.missed == 1
+	if first {
+		l.first = now
+	}
+
+	l.mu.Unlock()
+
+	switch {
+	case l.h.alertEvery <= 0:
+		l.flush()
+	case first:
+		time.AfterFunc(l.h.alertEvery, l.flush)
+	}
+}
+
+// flush publishes one alert with the messages lost since the last alert.
+// If the alert is rate limited the losses are kept and published by the next flush.
+func (l *lossCounter) flush() {
+	if !l.h.alerts.allow(l.h.now()) {
+		l.h.alerts.drop()
+
+		if l.h.alertEvery > 0 {
+			time.AfterFunc(l.h.alertEvery, l.flush)
+		}
+
+		return
+	}
+
+	l.mu.Lock()
+	f := Fields{
+		"missed":       l.missed,
+		"first":        l.first,
+		"last":         l.last,
+		"subscription": l.id,
+	}
+
+	if l.waited > 0 {
+		f["waited"] = l.waited
+	}
+
+	l.missed = 0
+	l.waited = 0
+	l.mu.Unlock()
+
+	l.h.publishAlert(l.topics, f)
+}
+
+// alert publishes the given fields on the alert topic if the rate limit allows it.
+func (h *Hub) alert(topics []string, f Fields) {
+	if !h.alerts.allow(h.now()) {
+		h.alerts.drop()
+		return
+	}
+
+	h.publishAlert(topics, f)
+}
+
+// publishAlert publishes the given fields on the alert topic.
+func (h *Hub) publishAlert(topics []string, f Fields) {
+	f["topic"] = topics
+	f["time"] = h.now()
+
+	h.Publish(Message{
+		Name:   h.alertTopic,
+		Fields: f,
+	})
+}
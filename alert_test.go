@@ -0,0 +1,159 @@
+package hub
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAlertsShouldNotLoopOnFullAlertSubscribers(t *testing.T) {
+	tests := []struct {
+		name  string
+		subFN func(h *Hub) Subscription
+	}{
+		{
+			name:  "non blocking",
+			subFN: func(h *Hub) Subscription { return h.NonBlockingSubscribe(1, "#") },
+		},
+		{
+			name:  "ring",
+			subFN: func(h *Hub) Subscription { return h.RingSubscribe(1, "#") },
+		},
+		{
+			name:  "timeout",
+			subFN: func(h *Hub) Subscription { return h.TimeoutSubscribe(0, time.Millisecond, "#") },
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			h := New(WithAlertRate(0), WithAlertInterval(0))
+			tt.subFN(h)
+
+			defer h.Close()
+
+			done := make(chan struct{})
+			go func() {
+				for i := 0; i < 3; i++ {
+					h.Publish(Message{Name: "a.b"})
+				}
+
+				close(done)
+			}()
+
+			select {
+			case <-done:
+			case <-time.After(time.Second):
+				require.FailNow(t, "publish should not recurse or deadlock alerting the alert subscribers")
+			}
+
+			require.NotZero(t, h.AlertsDropped())
+		})
+	}
+}
+
+func TestAlertsShouldBeRateLimited(t *testing.T) {
+	now := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	h := New(WithAlertRate(2), WithAlertInterval(0), WithClock(func() time.Time { return now }))
+	sub := h.NonBlockingSubscribe(1, "a")
+	alerts := h.NonBlockingSubscribe(10, AlertTopic)
+
+	defer h.Close()
+
+	for i := 0; i < 6; i++ {
+		h.Publish(Message{Name: "a"})
+	}
+
+	require.Len(t, alerts.Receiver, 2)
+	require.EqualValues(t, 3, h.AlertsDropped())
+
+	// after one second the bucket is full again.
+	now = now.Add(time.Second)
+
+	for i := 0; i < 3; i++ {
+		h.With(Fields{"child": true}).Publish(Message{Name: "a"})
+	}
+
+	require.Len(t, alerts.Receiver, 4)
+	require.EqualValues(t, 4, h.AlertsDropped())
+	require.Len(t, sub.Receiver, 1)
+
+	missed := 0
+	for i := 0; i < 4; i++ {
+		missed += (<-alerts.Receiver).Fields["missed"].(int)
+	}
+
+	// the 8 losses are published except the last one, which waits for the next alert.
+	require.Equal(t, 7, missed, "the losses of the rate limited alerts must be sent in the next alert")
+}
+
+func TestAggregatedAlertsShouldKeepTheLossesWhenRateLimited(t *testing.T) {
+	h := New(WithAlertRate(1), WithAlertInterval(10*time.Millisecond))
+	sub1 := h.NonBlockingSubscribe(1, "a")
+	sub2 := h.NonBlockingSubscribe(1, "a")
+	alerts := h.NonBlockingSubscribe(10, AlertTopic)
+
+	defer h.Close()
+
+	for i := 0; i < 4; i++ {
+		h.Publish(Message{Name: "a"})
+	}
+
+	received := map[uint64]int{}
+
+	for i := 0; i < 2; i++ {
+		select {
+		case msg := <-alerts.Receiver:
+			received[msg.Fields["subscription"].(uint64)] = msg.Fields["missed"].(int)
+		case <-time.After(3 * time.Second):
+			t.Fatal("the rate limited alert was never published")
+		}
+	}
+
+	require.Equal(t, map[uint64]int{sub1.ID(): 3, sub2.ID(): 3}, received)
+	require.NotZero(t, h.AlertsDropped())
+}
+
+func TestAlertsShouldBeAggregatedPerSubscription(t *testing.T) {
+	var (
+		mu  sync.Mutex
+		now = time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	)
+
+	h := New(WithAlertInterval(50*time.Millisecond), WithClock(func() time.Time {
+		mu.Lock()
+		defer mu.Unlock()
+
+		now = now.Add(time.Second)
+
+		return now
+	}))
+	sub1 := h.NonBlockingSubscribe(1, "a")
+	sub2 := h.NonBlockingSubscribe(1, "a", "b")
+	alerts := h.NonBlockingSubscribe(10, AlertTopic)
+
+	defer h.Close()
+
+	for i := 0; i < 4; i++ {
+		h.Publish(Message{Name: "a"})
+	}
+
+	received := map[uint64]Message{}
+
+	for i := 0; i < 2; i++ {
+		msg := <-alerts.Receiver
+		received[msg.Fields["subscription"].(uint64)] = msg
+	}
+
+	for _, sub := range []Subscription{sub1, sub2} {
+		msg := received[sub.ID()]
+		require.Equal(t, 3, msg.Fields["missed"])
+		require.Equal(t, sub.Topics, msg.Fields["topic"])
+		require.True(t, msg.Fields["first"].(time.Time).Before(msg.Fields["last"].(time.Time)))
+	}
+
+	require.Len(t, alerts.Receiver, 0, "only one alert should be published per subscription and interval")
+}
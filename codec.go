@@ -0,0 +1,191 @@
+package hub
+
+import (
+	"bytes"
+	"encoding/gob"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// The content types of the built in codecs.
+const (
+	ContentTypeJSON = "application/json"
+	ContentTypeGob  = "application/x-gob"
+)
+
+// ErrUnknownCodec is returned when there is no Codec registered for the message content type.
+var ErrUnknownCodec = errors.New("hub: unknown codec")
+
+type (
+	// Codec encodes values into the message Body and decodes them back.
+	// Codecs are identified by their content type and MUST be safe for concurrent use.
+	Codec interface {
+		// ContentType returns the content type set in the messages encoded by this Codec.
+		ContentType() string
+		// Encode returns the encoded value.
+		Encode(v interface{}) ([]byte, error)
+		// Decode stores the data inside the value pointed by v.
+		Decode(data []byte, v interface{}) error
+	}
+
+	// Validator is implemented by the Codecs able to check if some data is valid without decoding it.
+	// It's used by the hub to validate the messages published with a Body when the WithCodec option is used.
+	Validator interface {
+		Validate(data []byte) error
+	}
+
+	jsonCodec struct{}
+	gobCodec  struct{}
+)
+
+var codecs = struct {
+	sync.RWMutex
+	m map[string]Codec
+}{m: map[string]Codec{
+	ContentTypeJSON: jsonCodec{},
+	ContentTypeGob:  gobCodec{},
+}}
+
+// RegisterCodec adds the Codec to the registry replacing the Codec with the same content type.
+func RegisterCodec(c Codec) {
+	codecs.Lock()
+	defer codecs.Unlock()
+
+	codecs.m[mediaType(c.ContentType())] = c
+}
+
+// LookupCodec returns the registered Codec for the content type.
+// Content type parameters, like the charset, are ignored.
+func LookupCodec(contentType string) (Codec, error) {
+	codecs.RLock()
+	defer codecs.RUnlock()
+
+	c, ok := codecs.m[mediaType(contentType)]
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownCodec, contentType)
+	}
+
+	return c, nil
+}
+
+// mediaType returns the content type without parameters.
+func mediaType(contentType string) string {
+	return strings.ToLower(strings.TrimSpace(strings.Split(contentType, ";")[0]))
+}
+
+// EncodeBody encodes the value into the Body using the Codec registered for the content type
+// and sets the message ContentType.
+func (m *Message) EncodeBody(contentType string, v interface{}) error {
+	c, err := LookupCodec(contentType)
+	if err != nil {
+		return err
+	}
+
+	body, err := c.Encode(v)
+	if err != nil {
+		return fmt.Errorf("hub: encode %s: %w", contentType, err)
+	}
+
+	m.Body = body
+	m.ContentType = contentType
+
+	return nil
+}
+
+// DecodeBody decodes the Body into the value pointed by v using the Codec registered for the message ContentType.
+func (m *Message) DecodeBody(v interface{}) error {
+	c, err := LookupCodec(m.ContentType)
+	if err != nil {
+		return err
+	}
+
+	if err := c.Decode(m.Body, v); err != nil {
+		return fmt.Errorf("hub: decode %s: %w", m.ContentType, err)
+	}
+
+	return nil
+}
+
+// encode validates the message Body, or encodes the ValueField into the Body if it's empty,
+// using the hub codec or the codec registered for the message ContentType.
+func (h *Hub) encode(m Message) (Message, error) {
+	if h.codec == nil {
+		return m, nil
+	}
+
+	value, hasValue := m.Fields[ValueField]
+	if len(m.Body) == 0 && !hasValue {
+		return m, nil
+	}
+
+	if m.ContentType == "" {
+		m.ContentType = h.codec.ContentType()
+	}
+
+	c := h.codec
+	if mediaType(m.ContentType) != mediaType(c.ContentType()) {
+		var err error
+
+		if c, err = LookupCodec(m.ContentType); err != nil {
+			return m, err
+		}
+	}
+
+	if len(m.Body) == 0 {
+		body, err := c.Encode(value)
+		if err != nil {
+			return m, fmt.Errorf("hub: encode %s: %w", m.ContentType, err)
+		}
+
+		m.Body = body
+
+		return m, nil
+	}
+
+	if v, ok := c.(Validator); ok {
+		if err := v.Validate(m.Body); err != nil {
+			return m, fmt.Errorf("hub: invalid %s body: %w", m.ContentType, err)
+		}
+	}
+
+	return m, nil
+}
+
+func (jsonCodec) ContentType() string {
+	return ContentTypeJSON
+}
+
+func (jsonCodec) Encode(v interface{}) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+func (jsonCodec) Decode(data []byte, v interface{}) error {
+	return json.Unmarshal(data, v)
+}
+
+func (jsonCodec) Validate(data []byte) error {
+	if !json.Valid(data) {
+		return errors.New("malformed json")
+	}
+
+	return nil
+}
+
+func (gobCodec) ContentType() string {
+	return ContentTypeGob
+}
+
+func (gobCodec) Encode(v interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+
+	err := gob.NewEncoder(&buf).Encode(v)
+
+	return buf.Bytes(), err
+}
+
+func (gobCodec) Decode(data []byte, v interface{}) error {
+	return gob.NewDecoder(bytes.NewReader(data)).Decode(v)
+}
@@ -0,0 +1,157 @@
+package hub
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type upperCodec struct{}
+
+func (upperCodec) ContentType() string { return "text/upper" }
+
+func (upperCodec) Encode(v interface{}) ([]byte, error) {
+	s, ok := v.(string)
+	if !ok {
+		return nil, errors.New("not a string")
+	}
+
+	return []byte(strings.ToUpper(s)), nil
+}
+
+func (upperCodec) Decode(data []byte, v interface{}) error {
+	*(v.(*string)) = string(data)
+	return nil
+}
+
+func TestMessageBodyCodecs(t *testing.T) {
+	RegisterCodec(upperCodec{})
+
+	tests := map[string]struct {
+		contentType string
+		value       interface{}
+		decoded     func() interface{}
+		expected    interface{}
+		body        string
+	}{
+		"json": {
+			contentType: ContentTypeJSON,
+			value:       userCreated{ID: 1, Name: "alice"},
+			decoded:     func() interface{} { return &userCreated{} },
+			expected:    userCreated{ID: 1, Name: "alice"},
+			body:        `{"ID":1,"Name":"alice"}`,
+		},
+		"json with charset": {
+			contentType: "Application/JSON; charset=utf-8",
+			value:       []int{1, 2},
+			decoded:     func() interface{} { return &[]int{} },
+			expected:    []int{1, 2},
+			body:        `[1,2]`,
+		},
+		"gob": {
+			contentType: ContentTypeGob,
+			value:       userCreated{ID: 2, Name: "bob"},
+			decoded:     func() interface{} { return &userCreated{} },
+			expected:    userCreated{ID: 2, Name: "bob"},
+		},
+		"registered codec": {
+			contentType: "text/upper",
+			value:       "hello",
+			decoded:     func() interface{} { return new(string) },
+			expected:    "HELLO",
+			body:        "HELLO",
+		},
+	}
+
+	for name, tc := range tests {
+		tc := tc
+
+		t.Run(name, func(t *testing.T) {
+			var msg Message
+			require.NoError(t, msg.EncodeBody(tc.contentType, tc.value))
+			require.Equal(t, tc.contentType, msg.ContentType)
+
+			if tc.body != "" {
+				require.Equal(t, tc.body, string(msg.Body))
+			}
+
+			v := tc.decoded()
+			require.NoError(t, msg.DecodeBody(v))
+			require.Equal(t, tc.expected, reflect.ValueOf(v).Elem().Interface())
+		})
+	}
+
+	msg := Message{}
+	err := msg.EncodeBody("text/unknown", 1)
+	require.True(t, errors.Is(err, ErrUnknownCodec), "unexpected error: %v", err)
+
+	msg = Message{Body: []byte("{"), ContentType: ContentTypeJSON}
+	require.Error(t, msg.DecodeBody(&userCreated{}))
+	require.Error(t, (&Message{}).EncodeBody("text/upper", 1))
+}
+
+func TestWithCodecShouldEncodeAndValidateTheMessages(t *testing.T) {
+	h := New(WithCodec(jsonCodec{}))
+	sub := h.Subscribe(10, "a")
+	errs := h.Subscribe(10, ErrorTopic)
+
+	defer h.Close()
+
+	h.Publish(Message{Name: "a", Fields: Fields{ValueField: userCreated{ID: 1}}})
+	h.Publish(Message{Name: "a", Body: []byte(`{"id": 2}`)})
+	h.Publish(Message{Name: "a", Body: []byte("plain"), ContentType: "text/plain"})
+	h.Publish(Message{Name: "a", Body: []byte("{")})
+	h.Publish(Message{Name: "a", Fields: Fields{"id": 3}})
+
+	msg := <-sub.Receiver
+	require.Equal(t, ContentTypeJSON, msg.ContentType)
+	require.Equal(t, `{"ID":1,"Name":""}`, string(msg.Body))
+	require.Equal(t, userCreated{ID: 1}, msg.Fields[ValueField])
+
+	msg = <-sub.Receiver
+	require.Equal(t, ContentTypeJSON, msg.ContentType)
+	require.Equal(t, `{"id": 2}`, string(msg.Body))
+
+	msg = <-sub.Receiver
+	require.Equal(t, []Message{{Name: "a", Fields: Fields{"id": 3}}}, withoutMetadata(msg),
+		"messages without body or value are not changed")
+
+	for _, expected := range []error{ErrUnknownCodec, nil} {
+		msg = <-errs.Receiver
+		err := msg.Fields["error"].(error)
+
+		if expected != nil {
+			require.True(t, errors.Is(err, expected), "unexpected error: %v", err)
+		} else {
+			require.Contains(t, err.Error(), "invalid application/json body")
+		}
+
+		require.Equal(t, []string{"a"}, msg.Fields["topic"])
+	}
+
+	_, err := h.PublishContext(context.Background(), Message{Name: "a", Body: []byte("{")})
+	require.Error(t, err)
+	require.Empty(t, sub.Receiver)
+}
+
+func TestTopicShouldDecodeTheBody(t *testing.T) {
+	h := New()
+
+	defer h.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	values := NewTopic[userCreated](h, "user.created").Subscribe(ctx, 10)
+
+	var msg Message
+	require.NoError(t, msg.EncodeBody(ContentTypeGob, userCreated{ID: 1, Name: "alice"}))
+
+	msg.Name = "user.created"
+	h.Publish(msg)
+	require.Equal(t, userCreated{ID: 1, Name: "alice"}, <-values)
+}
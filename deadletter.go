@@ -0,0 +1,48 @@
+package hub
+
+import "time"
+
+// The reasons used by the DeadLetter.
+const (
+	// ReasonFull is used when the message was lost because the subscriber was full.
+	ReasonFull = "full"
+	// ReasonEvicted is used when the message was evicted by a newer message.
+	ReasonEvicted = "evicted"
+	// ReasonTimeout is used when the message was lost because the subscriber timeout was reached.
+	ReasonTimeout = "timeout"
+)
+
+// DeadLetter is a message lost by one subscription.
+// The dead letters are only created if the WithDeadLetterTopic or WithDeadLetterSink options are used.
+// Subscriber can be compared with Subscription.Subscriber and SubscriptionID with Subscription.ID.
+type DeadLetter struct {
+	Message        Message
+	Topics         []string
+	Subscriber     Subscriber
+	SubscriptionID uint64
+	Reason         string
+	Time           time.Time
+}
+
+// deadLetter sends the lost message to the dead letter sink and topic.
+// Messages lost from the dead letter topic are not sent again to avoid loops.
+func (h *Hub) deadLetter(dl DeadLetter) {
+	if h.deadLetterSink != nil {
+		h.deadLetterSink(dl)
+	}
+
+	if h.deadLetterTopic == "" || dl.Message.Name == h.deadLetterTopic {
+		return
+	}
+
+	h.Publish(Message{
+		Name: h.deadLetterTopic,
+		Fields: Fields{
+			"message":      dl.Message,
+			"topic":        dl.Topics,
+			"subscription": dl.SubscriptionID,
+			"reason":       dl.Reason,
+			"time":         dl.Time,
+		},
+	})
+}
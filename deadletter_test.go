@@ -0,0 +1,92 @@
+package hub
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDeadLetterTopic(t *testing.T) {
+	h := New(WithDeadLetterTopic("dead"))
+	sub := h.RingSubscribe(1, "a.*")
+	dead := h.NonBlockingSubscribe(10, "dead")
+
+	defer h.Close()
+
+	h.Publish(Message{Name: "a.b", Body: []byte("first")})
+	h.Publish(Message{Name: "a.b", Body: []byte("second")})
+
+	msg := <-dead.Receiver
+	require.Equal(t, []Message{{Name: "a.b", Body: []byte("first")}}, withoutMetadata(msg.Fields["message"].(Message)))
+	require.Equal(t, []string{"a.*"}, msg.Fields["topic"])
+	require.Equal(t, sub.ID(), msg.Fields["subscription"])
+	require.Equal(t, ReasonEvicted, msg.Fields["reason"])
+}
+
+func TestDeadLetterSink(t *testing.T) {
+	var (
+		mu      sync.Mutex
+		letters []DeadLetter
+	)
+
+	h := New(WithDeadLetterSink(func(dl DeadLetter) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		letters = append(letters, dl)
+	}))
+	full := h.NonBlockingSubscribe(1, "a")
+	timeout := h.TimeoutSubscribe(0, time.Millisecond, "a")
+
+	defer h.Close()
+
+	h.Publish(Message{Name: "a", Fields: Fields{"i": 1}})
+	h.Publish(Message{Name: "a", Fields: Fields{"i": 2}})
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	require.Len(t, letters, 3)
+
+	reasons := map[string]int{}
+	for _, dl := range letters {
+		reasons[dl.Reason]++
+
+		switch dl.Reason {
+		case ReasonFull:
+			require.Equal(t, full.Subscriber(), dl.Subscriber)
+			require.Equal(t, full.ID(), dl.SubscriptionID)
+			require.Equal(t, 2, dl.Message.Fields["i"])
+		case ReasonTimeout:
+			require.Equal(t, timeout.Subscriber(), dl.Subscriber)
+			require.Equal(t, timeout.ID(), dl.SubscriptionID)
+		}
+	}
+
+	require.Equal(t, map[string]int{ReasonFull: 1, ReasonTimeout: 2}, reasons)
+	require.NotEqual(t, full.ID(), timeout.ID())
+}
+
+func TestDeadLetterTopicShouldNotLoop(t *testing.T) {
+	h := New(WithDeadLetterTopic("dead"))
+	h.NonBlockingSubscribe(1, "#")
+
+	defer h.Close()
+
+	done := make(chan struct{})
+	go func() {
+		for i := 0; i < 3; i++ {
+			h.Publish(Message{Name: "a"})
+		}
+
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		require.FailNow(t, "dead letters lost by the dead letter subscribers should not be republished")
+	}
+}
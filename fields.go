@@ -0,0 +1,462 @@
+package hub
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrFieldNotFound is returned by the Message accessors when the field is not present.
+	ErrFieldNotFound = errors.New("hub: field not found")
+	// ErrFieldType is returned by the Message accessors and Fields.Decode when the field value
+	// can't be converted to the requested type.
+	ErrFieldType = errors.New("hub: invalid field type")
+)
+
+var (
+	durationType = reflect.TypeOf(time.Duration(0))
+	timeType     = reflect.TypeOf(time.Time{})
+	stringsType  = reflect.TypeOf([]string{})
+)
+
+// Int returns the field as an int.
+// Numbers are converted if they fit in an int without losing precision and strings are parsed.
+func (m *Message) Int(key string) (int, error) {
+	v, err := m.field(key)
+	if err != nil {
+		return 0, err
+	}
+
+	n, err := toInt(v, strconv.IntSize)
+
+	return int(n), fieldError(key, err)
+}
+
+// Int64 returns the field as an int64.
+// Numbers are converted if they fit in an int64 without losing precision and strings are parsed.
+func (m *Message) Int64(key string) (int64, error) {
+	v, err := m.field(key)
+	if err != nil {
+		return 0, err
+	}
+
+	n, err := toInt(v, 64)
+
+	return n, fieldError(key, err)
+}
+
+// Float64 returns the field as a float64.
+// Numbers are converted and strings are parsed.
+func (m *Message) Float64(key string) (float64, error) {
+	v, err := m.field(key)
+	if err != nil {
+		return 0, err
+	}
+
+	f, err := toFloat(v)
+
+	return f, fieldError(key, err)
+}
+
+// Bool returns the field as a bool.
+// Numbers are true if they are not zero and strings are parsed with strconv.ParseBool.
+func (m *Message) Bool(key string) (bool, error) {
+	v, err := m.field(key)
+	if err != nil {
+		return false, err
+	}
+
+	b, err := toBool(v)
+
+	return b, fieldError(key, err)
+}
+
+// String returns the field as a string.
+// Byte slices, numbers, bools, errors and fmt.Stringer values are formatted.
+func (m *Message) String(key string) (string, error) {
+	v, err := m.field(key)
+	if err != nil {
+		return "", err
+	}
+
+	s, err := toString(v)
+
+	return s, fieldError(key, err)
+}
+
+// Duration returns the field as a time.Duration.
+// Numbers are used as nanoseconds and strings are parsed with time.ParseDuration.
+func (m *Message) Duration(key string) (time.Duration, error) {
+	v, err := m.field(key)
+	if err != nil {
+		return 0, err
+	}
+
+	d, err := toDuration(v)
+
+	return d, fieldError(key, err)
+}
+
+// Time returns the field as a time.Time.
+// Numbers are used as Unix seconds and strings are parsed using the RFC3339 format.
+func (m *Message) Time(key string) (time.Time, error) {
+	v, err := m.field(key)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	t, err := toTime(v)
+
+	return t, fieldError(key, err)
+}
+
+// Strings returns the field as a []string.
+// Every item of other slices is converted like String and a single value returns a slice with one item.
+func (m *Message) Strings(key string) ([]string, error) {
+	v, err := m.field(key)
+	if err != nil {
+		return nil, err
+	}
+
+	s, err := toStrings(v)
+
+	return s, fieldError(key, err)
+}
+
+// Decode stores the fields inside the struct pointed by v.
+// The struct fields are filled using the key from the `hub` tag or the field name, fields tagged
+// with `hub:"-"` and keys not present are ignored. The values are converted like the Message accessors.
+func (f Fields) Decode(v interface{}) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("hub: decode needs a non-nil struct pointer, got %T", v)
+	}
+
+	rv = rv.Elem()
+	rt := rv.Type()
+
+	for i := 0; i < rt.NumField(); i++ {
+		sf := rt.Field(i)
+		if sf.PkgPath != "" {
+			continue
+		}
+
+		key := sf.Name
+		if tag, ok := sf.Tag.Lookup("hub"); ok {
+			key = strings.Split(tag, ",")[0]
+		}
+
+		if key == "-" {
+			continue
+		}
+
+		value, ok := f[key]
+		if !ok {
+			continue
+		}
+
+		if err := decode(rv.Field(i), value); err != nil {
+			return fieldError(key, err)
+		}
+	}
+
+	return nil
+}
+
+// field returns the value of the given key.
+func (m *Message) field(key string) (interface{}, error) {
+	v, ok := m.Fields[key]
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrFieldNotFound, key)
+	}
+
+	return v, nil
+}
+
+// fieldError adds the key to the conversion error.
+func fieldError(key string, err error) error {
+	if err == nil {
+		return nil
+	}
+
+	return fmt.Errorf("field %q: %w", key, err)
+}
+
+// typeError returns the error used when the value can't be converted to the type.
+func typeError(v interface{}, typ string) error {
+	return fmt.Errorf("%w: cannot convert %T to %s", ErrFieldType, v, typ)
+}
+
+// parseError returns the error used when the string can't be parsed.
+func parseError(s, typ string) error {
+	return fmt.Errorf("%w: cannot parse %q as %s", ErrFieldType, s, typ)
+}
+
+// decode stores the value inside the struct field converting it to the field type.
+// The field is not changed if the value can't be converted.
+func decode(field reflect.Value, v interface{}) error {
+	if v == nil {
+		field.Set(reflect.Zero(field.Type()))
+		return nil
+	}
+
+	converted, err := convert(v, field.Type())
+	if err != nil {
+		return err
+	}
+
+	field.Set(converted.Convert(field.Type()))
+
+	return nil
+}
+
+// convert converts the value to a reflect.Value convertible to the given type.
+func convert(v interface{}, typ reflect.Type) (reflect.Value, error) {
+	var (
+		converted interface{}
+		err       error
+	)
+
+	switch {
+	case typ == durationType:
+		converted, err = toDuration(v)
+	case typ == timeType:
+		converted, err = toTime(v)
+	case typ == stringsType:
+		converted, err = toStrings(v)
+	case typ.Kind() >= reflect.Int && typ.Kind() <= reflect.Int64:
+		converted, err = toInt(v, typ.Bits())
+	case typ.Kind() >= reflect.Uint && typ.Kind() <= reflect.Uint64:
+		converted, err = toUint(v, typ.Bits())
+	case typ.Kind() == reflect.Float32 || typ.Kind() == reflect.Float64:
+		converted, err = toFloat(v)
+	case typ.Kind() == reflect.Bool:
+		converted, err = toBool(v)
+	case typ.Kind() == reflect.String:
+		converted, err = toString(v)
+	case reflect.TypeOf(v).AssignableTo(typ):
+		converted = v
+	default:
+		err = typeError(v, typ.String())
+	}
+
+	return reflect.ValueOf(converted), err
+}
+
+// toUint converts the value to an unsigned integer which fits in the given bits.
+func toUint(v interface{}, bits int) (uint64, error) {
+	rv := reflect.ValueOf(v)
+	if k := rv.Kind(); k >= reflect.Uint && k <= reflect.Uintptr {
+		if u := rv.Uint(); bits == 64 || u < 1<<uint(bits) {
+			return u, nil
+		}
+	}
+
+	n, err := toInt(v, 64)
+	if err != nil {
+		return 0, err
+	}
+
+	if n < 0 || bits < 64 && n >= 1<<uint(bits) {
+		return 0, fmt.Errorf("%w: %v overflows uint%d", ErrFieldType, v, bits)
+	}
+
+	return uint64(n), nil
+}
+
+// toInt converts the value to an integer which fits in the given bits.
+func toInt(v interface{}, bits int) (int64, error) {
+	var (
+		n  int64
+		rv = reflect.ValueOf(v)
+	)
+
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n = rv.Int()
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		u := rv.Uint()
+		if u > math.MaxInt64 {
+			return 0, fmt.Errorf("%w: %v overflows int%d", ErrFieldType, v, bits)
+		}
+
+		n = int64(u)
+	case reflect.Float32, reflect.Float64:
+		f := rv.Float()
+		if f != math.Trunc(f) || f < math.MinInt64 || f >= math.MaxInt64 {
+			return 0, fmt.Errorf("%w: %v is not an int%d", ErrFieldType, v, bits)
+		}
+
+		n = int64(f)
+	case reflect.String:
+		var err error
+
+		n, err = strconv.ParseInt(rv.String(), 10, bits)
+		if err != nil {
+			return 0, parseError(rv.String(), "int")
+		}
+	default:
+		return 0, typeError(v, "int")
+	}
+
+	if bits < 64 && (n < -1<<uint(bits-1) || n >= 1<<uint(bits-1)) {
+		return 0, fmt.Errorf("%w: %v overflows int%d", ErrFieldType, v, bits)
+	}
+
+	return n, nil
+}
+
+// toFloat converts the value to a float64.
+func toFloat(v interface{}) (float64, error) {
+	rv := reflect.ValueOf(v)
+
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(rv.Int()), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return float64(rv.Uint()), nil
+	case reflect.Float32, reflect.Float64:
+		return rv.Float(), nil
+	case reflect.String:
+		f, err := strconv.ParseFloat(rv.String(), 64)
+		if err != nil {
+			return 0, parseError(rv.String(), "float64")
+		}
+
+		return f, nil
+	default:
+		return 0, typeError(v, "float64")
+	}
+}
+
+// toBool converts the value to a bool.
+func toBool(v interface{}) (bool, error) {
+	rv := reflect.ValueOf(v)
+
+	switch rv.Kind() {
+	case reflect.Bool:
+		return rv.Bool(), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return rv.Int() != 0, nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return rv.Uint() != 0, nil
+	case reflect.Float32, reflect.Float64:
+		return rv.Float() != 0, nil
+	case reflect.String:
+		b, err := strconv.ParseBool(rv.String())
+		if err != nil {
+			return false, parseError(rv.String(), "bool")
+		}
+
+		return b, nil
+	default:
+		return false, typeError(v, "bool")
+	}
+}
+
+// toString converts the value to a string.
+func toString(v interface{}) (string, error) {
+	switch s := v.(type) {
+	case string:
+		return s, nil
+	case []byte:
+		return string(s), nil
+	case error:
+		return s.Error(), nil
+	case fmt.Stringer:
+		return s.String(), nil
+	}
+
+	switch reflect.ValueOf(v).Kind() {
+	case reflect.String, reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64:
+		return fmt.Sprint(v), nil
+	default:
+		return "", typeError(v, "string")
+	}
+}
+
+// toDuration converts the value to a time.Duration.
+func toDuration(v interface{}) (time.Duration, error) {
+	switch d := v.(type) {
+	case time.Duration:
+		return d, nil
+	case string:
+		parsed, err := time.ParseDuration(d)
+		if err != nil {
+			return 0, parseError(d, "time.Duration")
+		}
+
+		return parsed, nil
+	}
+
+	n, err := toInt(v, 64)
+	if err != nil {
+		return 0, typeError(v, "time.Duration")
+	}
+
+	return time.Duration(n), nil
+}
+
+// toTime converts the value to a time.Time.
+func toTime(v interface{}) (time.Time, error) {
+	switch t := v.(type) {
+	case time.Time:
+		return t, nil
+	case *time.Time:
+		if t != nil {
+			return *t, nil
+		}
+	case string:
+		parsed, err := time.Parse(time.RFC3339Nano, t)
+		if err != nil {
+			return time.Time{}, parseError(t, "time.Time")
+		}
+
+		return parsed, nil
+	}
+
+	n, err := toInt(v, 64)
+	if err != nil {
+		return time.Time{}, typeError(v, "time.Time")
+	}
+
+	return time.Unix(n, 0), nil
+}
+
+// toStrings converts the value to a []string.
+func toStrings(v interface{}) ([]string, error) {
+	if s, ok := v.([]string); ok {
+		return s, nil
+	}
+
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array || rv.Type().Elem().Kind() == reflect.Uint8 {
+		s, err := toString(v)
+		if err != nil {
+			return nil, typeError(v, "[]string")
+		}
+
+		return []string{s}, nil
+	}
+
+	s := make([]string, rv.Len())
+
+	for i := range s {
+		item, err := toString(rv.Index(i).Interface())
+		if err != nil {
+			return nil, typeError(v, "[]string")
+		}
+
+		s[i] = item
+	}
+
+	return s, nil
+}
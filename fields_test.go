@@ -0,0 +1,204 @@
+package hub
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMessageAccessorsShouldConvertTheFields(t *testing.T) {
+	now := time.Date(2020, 1, 2, 3, 4, 5, 6, time.UTC)
+	msg := Message{Name: "a", Fields: Fields{
+		"int":         42,
+		"int8":        int8(-8),
+		"uint":        uint(7),
+		"float":       3.0,
+		"fraction":    3.5,
+		"string":      "12",
+		"number":      json.Number("13"),
+		"bool":        true,
+		"boolString":  "true",
+		"bytes":       []byte("bytes"),
+		"error":       errors.New("failed"),
+		"duration":    time.Second,
+		"durationStr": "1m30s",
+		"time":        now,
+		"timeStr":     now.Format(time.RFC3339Nano),
+		"unix":        int64(1577934245),
+		"strings":     []string{"a", "b"},
+		"values":      []interface{}{"a", 1, true},
+	}}
+
+	tests := map[string]struct {
+		get      func() (interface{}, error)
+		expected interface{}
+	}{
+		"int from int":                {func() (interface{}, error) { return msg.Int("int") }, 42},
+		"int from int8":               {func() (interface{}, error) { return msg.Int("int8") }, -8},
+		"int from uint":               {func() (interface{}, error) { return msg.Int("uint") }, 7},
+		"int from float":              {func() (interface{}, error) { return msg.Int("float") }, 3},
+		"int from string":             {func() (interface{}, error) { return msg.Int("string") }, 12},
+		"int64 from json.Number":      {func() (interface{}, error) { return msg.Int64("number") }, int64(13)},
+		"float64 from float":          {func() (interface{}, error) { return msg.Float64("fraction") }, 3.5},
+		"float64 from int":            {func() (interface{}, error) { return msg.Float64("int") }, 42.0},
+		"float64 from string":         {func() (interface{}, error) { return msg.Float64("string") }, 12.0},
+		"bool from bool":              {func() (interface{}, error) { return msg.Bool("bool") }, true},
+		"bool from string":            {func() (interface{}, error) { return msg.Bool("boolString") }, true},
+		"bool from int":               {func() (interface{}, error) { return msg.Bool("int") }, true},
+		"string from string":          {func() (interface{}, error) { return msg.String("string") }, "12"},
+		"string from bytes":           {func() (interface{}, error) { return msg.String("bytes") }, "bytes"},
+		"string from int":             {func() (interface{}, error) { return msg.String("int") }, "42"},
+		"string from error":           {func() (interface{}, error) { return msg.String("error") }, "failed"},
+		"string from stringer":        {func() (interface{}, error) { return msg.String("duration") }, "1s"},
+		"duration from duration":      {func() (interface{}, error) { return msg.Duration("duration") }, time.Second},
+		"duration from string":        {func() (interface{}, error) { return msg.Duration("durationStr") }, 90 * time.Second},
+		"duration from int":           {func() (interface{}, error) { return msg.Duration("int") }, time.Duration(42)},
+		"time from time":              {func() (interface{}, error) { return msg.Time("time") }, now},
+		"time from string":            {func() (interface{}, error) { return msg.Time("timeStr") }, now},
+		"time from unix":              {func() (interface{}, error) { return msg.Time("unix") }, time.Unix(1577934245, 0)},
+		"strings from strings":        {func() (interface{}, error) { return msg.Strings("strings") }, []string{"a", "b"}},
+		"strings from values":         {func() (interface{}, error) { return msg.Strings("values") }, []string{"a", "1", "true"}},
+		"strings from a single value": {func() (interface{}, error) { return msg.Strings("string") }, []string{"12"}},
+	}
+
+	for name, tc := range tests {
+		tc := tc
+
+		t.Run(name, func(t *testing.T) {
+			v, err := tc.get()
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, v)
+		})
+	}
+}
+
+func TestMessageAccessorsShouldReturnErrors(t *testing.T) {
+	msg := Message{Name: "a", Fields: Fields{
+		"fraction": 3.5,
+		"text":     "abc",
+		"big":      int64(1) << 40,
+		"negative": -1,
+		"map":      map[string]int{},
+	}}
+
+	tests := map[string]struct {
+		get      func() (interface{}, error)
+		expected error
+	}{
+		"missing field":        {func() (interface{}, error) { return msg.Int("missing") }, ErrFieldNotFound},
+		"int from fraction":    {func() (interface{}, error) { return msg.Int("fraction") }, ErrFieldType},
+		"int from text":        {func() (interface{}, error) { return msg.Int64("text") }, ErrFieldType},
+		"float from map":       {func() (interface{}, error) { return msg.Float64("map") }, ErrFieldType},
+		"bool from text":       {func() (interface{}, error) { return msg.Bool("text") }, ErrFieldType},
+		"string from map":      {func() (interface{}, error) { return msg.String("map") }, ErrFieldType},
+		"duration from text":   {func() (interface{}, error) { return msg.Duration("text") }, ErrFieldType},
+		"time from text":       {func() (interface{}, error) { return msg.Time("text") }, ErrFieldType},
+		"strings from map":     {func() (interface{}, error) { return msg.Strings("map") }, ErrFieldType},
+		"missing with no keys": {func() (interface{}, error) { return (&Message{}).Bool("a") }, ErrFieldNotFound},
+	}
+
+	for name, tc := range tests {
+		tc := tc
+
+		t.Run(name, func(t *testing.T) {
+			_, err := tc.get()
+			require.True(t, errors.Is(err, tc.expected), "unexpected error: %v", err)
+		})
+	}
+
+	var decoded struct {
+		Small int8
+		U     uint
+	}
+
+	err := Fields{"Small": msg.Fields["big"]}.Decode(&decoded)
+	require.True(t, errors.Is(err, ErrFieldType), "unexpected error: %v", err)
+	require.Contains(t, err.Error(), `field "Small"`)
+
+	err = Fields{"U": msg.Fields["negative"]}.Decode(&decoded)
+	require.True(t, errors.Is(err, ErrFieldType), "unexpected error: %v", err)
+}
+
+type level string
+
+type decoded struct {
+	ID       int64         `hub:"id"`
+	Name     string        `hub:"name"`
+	Level    level         `hub:"level"`
+	Ratio    float32       `hub:"ratio"`
+	Count    uint16        `hub:"count"`
+	Enabled  bool          `hub:"enabled"`
+	Timeout  time.Duration `hub:"timeout"`
+	At       time.Time     `hub:"at"`
+	Tags     []string      `hub:"tags"`
+	Extra    interface{}   `hub:"extra"`
+	Fields   Fields        `hub:"fields"`
+	Ignored  string        `hub:"-"`
+	Untagged string
+	Missing  string `hub:"missing"`
+	private  string
+}
+
+func TestFieldsDecodeShouldFillTheStruct(t *testing.T) {
+	at := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	f := Fields{
+		"id":       "10",
+		"name":     "event",
+		"level":    "debug",
+		"ratio":    0.5,
+		"count":    3.0,
+		"enabled":  "1",
+		"timeout":  "2s",
+		"at":       at.Format(time.RFC3339),
+		"tags":     []interface{}{"a", "b"},
+		"extra":    []int{1},
+		"fields":   Fields{"a": 1},
+		"-":        "ignored",
+		"Ignored":  "ignored",
+		"Untagged": 1,
+		"private":  "private",
+	}
+
+	d := decoded{Missing: "kept"}
+	require.NoError(t, f.Decode(&d))
+	require.Equal(t, decoded{
+		ID:       10,
+		Name:     "event",
+		Level:    "debug",
+		Ratio:    0.5,
+		Count:    3,
+		Enabled:  true,
+		Timeout:  2 * time.Second,
+		At:       at,
+		Tags:     []string{"a", "b"},
+		Extra:    []int{1},
+		Fields:   Fields{"a": 1},
+		Untagged: "1",
+		Missing:  "kept",
+	}, d)
+
+	require.Error(t, f.Decode(d))
+	require.Error(t, f.Decode(nil))
+	require.Error(t, Fields{"fields": "text"}.Decode(&d))
+	require.Equal(t, Fields{"a": 1}, d.Fields, "the field must not change when the value is invalid")
+}
+
+func ExampleFields_Decode() {
+	var event struct {
+		User    string        `hub:"user"`
+		Retries int           `hub:"retries"`
+		Timeout time.Duration `hub:"timeout"`
+	}
+
+	f := Fields{"user": "alice", "retries": "3", "timeout": "1s"}
+	if err := f.Decode(&event); err != nil {
+		panic(err)
+	}
+
+	fmt.Println(event.User, event.Retries, event.Timeout)
+	// Output: alice 3 1s
+}
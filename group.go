@@ -0,0 +1,394 @@
+package hub
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+)
+
+type (
+	// groups keeps the queue groups of one hub.
+	groups struct {
+		mu          sync.Mutex
+		dispatchers map[groupKey]*groupSubscriber
+		members     map[Subscriber][]groupKey
+		ids         uint64
+	}
+
+	// groupKey identifies one group dispatcher, partitioned groups are identified by the partition key too.
+	groupKey struct {
+		group       string
+		topic       string
+		partition   string
+		partitioned bool
+	}
+
+	// groupSubscriber is subscribed into the matcher and delivers each message to only one of its members.
+	// The dispatchers of the same group matching one message are merged by mergeGroups, so the round-robin
+	// counter is shared with the merged dispatcher.
+	groupSubscriber struct {
+		mu          sync.RWMutex
+		members     []groupMember
+		next        *uint32
+		group       string
+		partition   string
+		partitioned bool
+	}
+
+	groupMember struct {
+		id  uint64
+		sub Subscriber
+	}
+)
+
+func newGroups() *groups {
+	return &groups{
+		dispatchers: make(map[groupKey]*groupSubscriber),
+		members:     make(map[Subscriber][]groupKey),
+	}
+}
+
+// SubscribeGroup create a blocking subscription to receive events for a given topic as a member of the group.
+// Each message is delivered to only one member of the group subscribed to the same topic, chosen using round-robin
+// and skipping the members with more pending messages. Subscriptions outside the group still receive their copy.
+func (h *Hub) SubscribeGroup(group string, cap int, topics ...string) Subscription {
+	member := newBlockingSubscriber(cap)
+	h.groups.join(h.matcher, group, topics, member)
+
+	sub := NewSubscription(topics, member)
+	sub.id = h.nextID()
+
+	if h.hooks.OnSubscribe != nil {
+		h.hooks.OnSubscribe(sub)
+	}
+
+	return sub
+}
+
+// SubscribePartitioned create a blocking subscription to receive events for a given topic as a member of the
+// partitioned group. Each message is delivered to only one member of the group subscribed to the same topic, chosen
+// by hashing the value of the given Fields key (or the topic if the key is empty or not present in the message).
+// So the messages with the same key are always delivered in order to the same member while the members don't change.
+// When members join or leave the group only the keys of the changed members are moved to another member.
+func (h *Hub) SubscribePartitioned(group, key string, cap int, topics ...string) Subscription {
+	member := newBlockingSubscriber(cap)
+	h.groups.joinPartitioned(h.matcher, group, key, topics, member)
+
+	sub := NewSubscription(topics, member)
+	sub.id = h.nextID()
+
+	if h.hooks.OnSubscribe != nil {
+		h.hooks.OnSubscribe(sub)
+	}
+
+	return sub
+}
+
+// join adds the member into the group dispatchers of each topic, subscribing the new dispatchers.
+func (g *groups) join(m Matcher, group string, topics []string, member Subscriber) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	id := g.nextID()
+	for _, topic := range topics {
+		g.add(m, groupKey{group: group, topic: topic}, groupMember{id: id, sub: member})
+	}
+}
+
+// joinPartitioned adds the member into the partitioned group dispatchers of each topic.
+func (g *groups) joinPartitioned(m Matcher, group, partition string, topics []string, member Subscriber) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	id := g.nextID()
+	for _, topic := range topics {
+		key := groupKey{group: group, topic: topic, partition: partition, partitioned: true}
+		g.add(m, key, groupMember{id: id, sub: member})
+	}
+}
+
+// nextID returns a new member id, the same id is used in all the dispatchers of the member.
+// This must be called with the lock held.
+func (g *groups) nextID() uint64 {
+	g.ids++
+
+	return g.ids
+}
+
+// add inserts the member into the group dispatcher, subscribing the dispatcher if it's new.
+// Repeated topics are ignored, so the member is added only once into each dispatcher.
+// This must be called with the lock held.
+func (g *groups) add(m Matcher, key groupKey, member groupMember) {
+	for _, k := range g.members[member.sub] {
+		if k == key {
+			return
+		}
+	}
+
+	d, ok := g.dispatchers[key]
+	if !ok {
+		d = &groupSubscriber{next: new(uint32), group: key.group, partition: key.partition, partitioned: key.partitioned}
+		g.dispatchers[key] = d
+		m.Subscribe([]string{key.topic}, d)
+	}
+
+	d.add(member)
+	g.members[member.sub] = append(g.members[member.sub], key)
+}
+
+// leave removes the member from its group dispatchers, unsubscribing the empty ones.
+// It returns false if the subscriber is not a member of any group.
+func (g *groups) leave(m Matcher, member Subscriber) bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	keys, ok := g.members[member]
+	if !ok {
+		return false
+	}
+
+	for _, key := range keys {
+		d := g.dispatchers[key]
+		if d.remove(member) == 0 {
+			m.Unsubscribe(NewSubscription([]string{key.topic}, d))
+			delete(g.dispatchers, key)
+		}
+	}
+
+	delete(g.members, member)
+
+	return true
+}
+
+// clear removes all the groups and returns the subscriptions of their members.
+func (g *groups) clear() []Subscription {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	subs := make([]Subscription, 0, len(g.members))
+
+	for member, keys := range g.members {
+		topics := make([]string, len(keys))
+		for i, key := range keys {
+			topics[i] = key.topic
+		}
+
+		subs = append(subs, NewSubscription(topics, member))
+	}
+
+	g.dispatchers = make(map[groupKey]*groupSubscriber)
+	g.members = make(map[Subscriber][]groupKey)
+
+	return subs
+}
+
+// mergeGroups replaces the dispatchers of the same group by one dispatcher with all their members, so a group
+// receives each message only once even if its members subscribed to overlapping topics, like "job.*" and "job.#".
+func mergeGroups(subs []Subscriber) []Subscriber {
+	var (
+		seen       map[groupKey]bool
+		overlapped bool
+	)
+
+	for _, sub := range subs {
+		d, ok := sub.(*groupSubscriber)
+		if !ok {
+			continue
+		}
+
+		if seen == nil {
+			seen = make(map[groupKey]bool)
+		}
+
+		overlapped = overlapped || seen[d.key()]
+		seen[d.key()] = true
+	}
+
+	if !overlapped {
+		return subs
+	}
+
+	merged := make(map[groupKey]*groupSubscriber, len(seen))
+	result := make([]Subscriber, 0, len(subs))
+
+	for _, sub := range subs {
+		d, ok := sub.(*groupSubscriber)
+		if !ok {
+			result = append(result, sub)
+			continue
+		}
+
+		m, ok := merged[d.key()]
+		if !ok {
+			m = &groupSubscriber{next: d.next, group: d.group, partition: d.partition, partitioned: d.partitioned}
+			merged[d.key()] = m
+			result = append(result, m)
+		}
+
+		m.merge(d)
+	}
+
+	return result
+}
+
+// key returns the key identifying the group of the dispatcher, without the topic.
+func (s *groupSubscriber) key() groupKey {
+	return groupKey{group: s.group, partition: s.partition, partitioned: s.partitioned}
+}
+
+// add inserts the member into the group.
+func (s *groupSubscriber) add(member groupMember) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.members = append(s.members, member)
+}
+
+// merge adds the members of the other dispatcher which are not members of this one.
+func (s *groupSubscriber) merge(other *groupSubscriber) {
+	other.mu.RLock()
+	defer other.mu.RUnlock()
+
+	for _, member := range other.members {
+		if !s.has(member.sub) {
+			s.members = append(s.members, member)
+		}
+	}
+}
+
+// has returns true if the subscriber is a member of the group.
+func (s *groupSubscriber) has(sub Subscriber) bool {
+	for _, m := range s.members {
+		if m.sub == sub {
+			return true
+		}
+	}
+
+	return false
+}
+
+// remove deletes the member from the group and returns the number of members left.
+func (s *groupSubscriber) remove(member Subscriber) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	members := make([]groupMember, 0, len(s.members))
+
+	for _, m := range s.members {
+		if m.sub != member {
+			members = append(members, m)
+		}
+	}
+
+	s.members = members
+
+	return len(members)
+}
+
+// pick returns the member which should receive the message.
+func (s *groupSubscriber) pick(msg Message) Subscriber {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if len(s.members) == 0 {
+		return nil
+	}
+
+	if s.partitioned {
+		return s.partitionOwner(msg)
+	}
+
+	return s.leastLoaded()
+}
+
+// leastLoaded returns the next member using round-robin, skipping the members with more pending messages.
+func (s *groupSubscriber) leastLoaded() Subscriber {
+	n := len(s.members)
+	start := int(atomic.AddUint32(s.next, 1) % uint32(n))
+	best := s.members[start].sub
+	pending := len(best.Ch())
+
+	for i := 1; i < n && pending > 0; i++ {
+		m := s.members[(start+i)%n].sub
+		if l := len(m.Ch()); l < pending {
+			best, pending = m, l
+		}
+	}
+
+	return best
+}
+
+// partitionOwner returns the member owning the message key using rendezvous hashing.
+func (s *groupSubscriber) partitionOwner(msg Message) Subscriber {
+	key := msg.Topic()
+	if v, ok := msg.Fields[s.partition]; ok && s.partition != "" {
+		key = fmt.Sprint(v)
+	}
+
+	var (
+		best      Subscriber
+		bestScore uint64
+	)
+
+	for _, m := range s.members {
+		if score := rendezvousScore(key, m.id); best == nil || score > bestScore {
+			best, bestScore = m.sub, score
+		}
+	}
+
+	return best
+}
+
+// rendezvousScore returns the hash of the key for the given member id using FNV-1a
+// and the splitmix64 finalizer to spread the member ids.
+func rendezvousScore(key string, id uint64) uint64 {
+	const (
+		offset = 14695981039346656037
+		prime  = 1099511628211
+	)
+
+	h := uint64(offset)
+	for i := 0; i < len(key); i++ {
+		h ^= uint64(key[i])
+		h *= prime
+	}
+
+	h ^= id
+	h ^= h >> 30
+	h *= 0xbf58476d1ce4e5b9
+	h ^= h >> 27
+	h *= 0x94d049bb133111eb
+	h ^= h >> 31
+
+	return h
+}
+
+// Set sends the message to one of the members.
+func (s *groupSubscriber) Set(msg Message) {
+	s.SetContext(context.Background(), msg)
+}
+
+// SetContext sends the message to one of the members and report the member delivery.
+func (s *groupSubscriber) SetContext(ctx context.Context, msg Message) delivery {
+	member := s.pick(msg)
+	if member == nil {
+		return dropped
+	}
+
+	if cs, ok := member.(contextSubscriber); ok {
+		return cs.SetContext(ctx, msg)
+	}
+
+	member.Set(msg)
+
+	return delivered
+}
+
+// Ch return a nil channel, the messages are consumed using the members channels.
+func (s *groupSubscriber) Ch() <-chan Message {
+	return nil
+}
+
+// Close does nothing, the members are closed by the hub.
+func (s *groupSubscriber) Close() {}
@@ -0,0 +1,224 @@
+package hub
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSubscribeGroup(t *testing.T) {
+	h := New()
+	members := []Subscription{
+		h.SubscribeGroup("workers", 10, "job.*"),
+		h.SubscribeGroup("workers", 10, "job.*"),
+		h.SubscribeGroup("workers", 10, "job.*"),
+	}
+	independent := h.Subscribe(20, "job.*")
+	other := h.SubscribeGroup("others", 20, "job.*")
+
+	for i := 0; i < 9; i++ {
+		h.Publish(Message{Name: "job.run"})
+	}
+
+	for _, m := range members {
+		require.Len(t, m.Receiver, 3, "the messages should be balanced between the members")
+	}
+
+	require.Len(t, independent.Receiver, 9)
+	require.Len(t, other.Receiver, 9)
+
+	h.Unsubscribe(members[0])
+
+	for i := 0; i < 4; i++ {
+		h.Publish(Message{Name: "job.run"})
+	}
+
+	require.Len(t, members[1].Receiver, 5)
+	require.Len(t, members[2].Receiver, 5)
+
+	h.Unsubscribe(members[1])
+	h.Unsubscribe(members[2])
+	h.Unsubscribe(other)
+	require.Len(t, h.matcher.Subscriptions(), 1, "empty groups should be removed from the matcher")
+
+	h.Close()
+
+	// all the members are closed, so ranging over them must finish.
+	for _, m := range append(members, independent, other) {
+		for range m.Receiver {
+		}
+	}
+}
+
+func TestSubscribeGroupShouldPreferTheLeastLoadedMember(t *testing.T) {
+	h := New()
+	busy := h.SubscribeGroup("workers", 10, "job")
+	idle := h.SubscribeGroup("workers", 10, "job")
+
+	defer h.Close()
+
+	for i := 0; i < 4; i++ {
+		h.Publish(Message{Name: "job"})
+	}
+
+	// the idle member consumes everything, so it should receive all the next messages.
+	for len(idle.Receiver) > 0 {
+		<-idle.Receiver
+	}
+
+	for i := 0; i < 2; i++ {
+		h.Publish(Message{Name: "job"})
+		<-idle.Receiver
+	}
+
+	require.Len(t, busy.Receiver, 2)
+}
+
+func TestCloseShouldCloseGroupMembers(t *testing.T) {
+	var unsubscribed int
+
+	h := New(WithHooks(Hooks{OnUnsubscribe: func(Subscription) { unsubscribed++ }}))
+	sub := h.SubscribeGroup("workers", 1, "a", "b")
+
+	h.Close()
+
+	_, ok := <-sub.Receiver
+	require.False(t, ok)
+	require.Equal(t, 1, unsubscribed)
+	require.Len(t, h.matcher.Subscriptions(), 0)
+
+	h.Publish(Message{Name: "a"})
+}
+
+func TestGroupsShouldIgnoreRepeatedTopics(t *testing.T) {
+	h := New()
+	repeated := []Subscription{
+		h.SubscribeGroup("workers", 10, "a", "a"),
+		h.SubscribePartitioned("partitioned", "", 10, "a", "a"),
+	}
+	member := h.SubscribeGroup("workers", 10, "a")
+
+	defer h.Close()
+
+	for i := 0; i < 4; i++ {
+		h.Publish(Message{Name: "a"})
+	}
+
+	require.Len(t, repeated[0].Receiver, 2, "the repeated topic must not change the round-robin weight")
+	require.Len(t, member.Receiver, 2)
+	require.Len(t, repeated[1].Receiver, 4)
+
+	for _, sub := range repeated {
+		h.Unsubscribe(sub)
+	}
+
+	h.Unsubscribe(member)
+	require.Len(t, h.matcher.Subscriptions(), 0)
+}
+
+func TestSubscribePartitioned(t *testing.T) {
+	h := New()
+	members := []Subscription{
+		h.SubscribePartitioned("workers", "id", 100, "order.*"),
+		h.SubscribePartitioned("workers", "id", 100, "order.*"),
+		h.SubscribePartitioned("workers", "id", 100, "order.*"),
+	}
+
+	defer h.Close()
+
+	owners := publishOrders(t, h, members)
+	require.Len(t, owners, 20)
+
+	used := map[int]bool{}
+	for _, owner := range owners {
+		used[owner] = true
+	}
+
+	require.Len(t, used, 3, "the keys should be spread between the members")
+
+	// the keys of the members left must not move.
+	h.Unsubscribe(members[0])
+
+	for id, owner := range publishOrders(t, h, members[1:]) {
+		if owners[id] != 0 {
+			require.Equal(t, owners[id], owner+1)
+		}
+	}
+}
+
+// publishOrders publishes 5 messages for 20 keys and returns the member index receiving each key.
+func publishOrders(t *testing.T, h *Hub, members []Subscription) map[int]int {
+	t.Helper()
+
+	for i := 0; i < 5; i++ {
+		for id := 0; id < 20; id++ {
+			h.Publish(Message{Name: "order.updated", Fields: Fields{"id": id, "seq": i}})
+		}
+	}
+
+	owners := map[int]int{}
+	last := map[int]int{}
+
+	for idx, m := range members {
+		for len(m.Receiver) > 0 {
+			msg := <-m.Receiver
+			id := msg.Fields["id"].(int)
+
+			if owner, ok := owners[id]; ok {
+				require.Equal(t, owner, idx, "the same key was delivered to different members")
+				require.Equal(t, last[id]+1, msg.Fields["seq"], "the messages with the same key should be delivered in order")
+			}
+
+			owners[id] = idx
+			last[id] = msg.Fields["seq"].(int)
+		}
+	}
+
+	return owners
+}
+
+func TestGroupsShouldDeliverOnceWithOverlappingTopics(t *testing.T) {
+	tests := map[string]func(h *Hub) []Subscription{
+		"members with overlapping topics": func(h *Hub) []Subscription {
+			return []Subscription{
+				h.SubscribeGroup("workers", 10, "job.*"),
+				h.SubscribeGroup("workers", 10, "job.run"),
+			}
+		},
+		"member with overlapping topics": func(h *Hub) []Subscription {
+			return []Subscription{h.SubscribeGroup("workers", 10, "job.*", "job.#")}
+		},
+		"partitioned members with overlapping topics": func(h *Hub) []Subscription {
+			return []Subscription{
+				h.SubscribePartitioned("workers", "", 10, "job.*"),
+				h.SubscribePartitioned("workers", "", 10, "job.#", "job.run"),
+			}
+		},
+	}
+
+	for name, subscribe := range tests {
+		subscribe := subscribe
+
+		t.Run(name, func(t *testing.T) {
+			h := New()
+			members := subscribe(h)
+			other := h.SubscribeGroup("others", 10, "job.#")
+
+			defer h.Close()
+
+			for i := 0; i < 4; i++ {
+				h.Publish(Message{Name: "job.run"})
+			}
+
+			h.PublishRetained(Message{Name: "job.run"})
+
+			received := 0
+			for _, m := range members {
+				received += len(m.Receiver)
+			}
+
+			require.Equal(t, 5, received, "the group must receive each message once")
+			require.Len(t, other.Receiver, 5)
+		})
+	}
+}
@@ -0,0 +1,174 @@
+package hub
+
+import (
+	"fmt"
+	"sync"
+)
+
+// ErrorTopic is used to notify when a handler returns an error or panics.
+// You can subscribe on this topic and log or send metrics.
+// The topic can be changed using the WithErrorTopic option.
+const ErrorTopic = "hub.handler.error"
+
+type (
+	// HandlerFunc is a function used to process the messages received by a subscription.
+	HandlerFunc func(Message) error
+
+	// HandlerOption is used to change the default behavior of Handle.
+	HandlerOption func(*handlerOptions)
+
+	handlerOptions struct {
+		workers  int
+		capacity int
+	}
+
+	// errorQueue publishes the errors of the handlers from its own goroutine, so the workers never wait
+	// for the subscribers of the error topic, which can be other handlers waiting for them.
+	errorQueue struct {
+		mu      sync.Mutex
+		pending []func()
+		wake    chan struct{}
+		done    chan struct{}
+		start   sync.Once
+		stop    sync.Once
+	}
+)
+
+// HandlerWorkers sets the number of goroutines used to process the messages.
+// The default is one worker, so the messages are processed in order.
+func HandlerWorkers(n int) HandlerOption {
+	return func(o *handlerOptions) {
+		if n > 0 {
+			o.workers = n
+		}
+	}
+}
+
+// HandlerCapacity sets the capacity of the blocking subscription used by the handler.
+// The default capacity is 0 (unbuffered).
+func HandlerCapacity(cap int) HandlerOption {
+	return func(o *handlerOptions) {
+		o.capacity = cap
+	}
+}
+
+// Handle create a blocking subscription for the given topics and process the messages with the given function
+// using a pool of goroutines. Errors and panics returned by the function are published on the error topic.
+// The errors are published in order by another goroutine, so the workers never wait for the subscribers of the
+// error topic. The errors are not sent to the handler subscription and the errors about messages of the error
+// topic are discarded to avoid loops.
+// The workers stop after the subscription is removed with Unsubscribe or the hub is closed.
+func (h *Hub) Handle(topics []string, fn HandlerFunc, opts ...HandlerOption) Subscription {
+	o := handlerOptions{workers: 1}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	sub := h.Subscribe(o.capacity, topics...)
+	for i := 0; i < o.workers; i++ {
+		go h.handle(sub, fn)
+	}
+
+	return sub
+}
+
+// handle process the messages received by the subscription until the channel is closed.
+func (h *Hub) handle(sub Subscription, fn HandlerFunc) {
+	for msg := range sub.Receiver {
+		if err := call(fn, msg); err != nil {
+			h.queueError(err, msg, sub.Topics, sub.subscriber)
+		}
+	}
+}
+
+// queueError publishes the error like publishError without waiting for the subscribers of the error topic.
+func (h *Hub) queueError(err error, msg Message, topics []string, except Subscriber) {
+	h.errors.push(func() { h.publishError(err, msg, topics, except) })
+}
+
+// publishError publishes the error returned while processing the message on the error topic as caused by it.
+// The error is not sent to the given subscriber, which is processing the message, and errors about messages of
+// the error topic are discarded because they could recurse without bound.
+func (h *Hub) publishError(err error, msg Message, topics []string, except Subscriber) {
+	if msg.Name == h.errorTopic {
+		return
+	}
+
+	h.publish(Message{
+		Name: h.errorTopic,
+		Fields: Fields{
+			"error":   err,
+			"message": msg,
+			"topic":   topics,
+			"time":    h.now(),
+		},
+	}.CausedBy(msg), except)
+}
+
+// call executes the function converting panics into errors.
+func call(fn HandlerFunc, msg Message) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("hub: handler panic: %v", r)
+		}
+	}()
+
+	return fn(msg)
+}
+
+// newErrorQueue returns an empty errorQueue, its goroutine is started by the first error.
+func newErrorQueue() *errorQueue {
+	return &errorQueue{wake: make(chan struct{}, 1), done: make(chan struct{})}
+}
+
+// push adds the publish into the queue. It's discarded if the queue is closed.
+func (q *errorQueue) push(publish func()) {
+	q.start.Do(func() { go q.run() })
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	select {
+	case <-q.done:
+		return
+	default:
+	}
+
+	q.pending = append(q.pending, publish)
+
+	select {
+	case q.wake <- struct{}{}:
+	default:
+	}
+}
+
+// run executes the queued publishes in order until the queue is closed.
+func (q *errorQueue) run() {
+	for {
+		select {
+		case <-q.wake:
+		case <-q.done:
+			return
+		}
+
+		q.mu.Lock()
+		pending := q.pending
+		q.pending = nil
+		q.mu.Unlock()
+
+		for _, publish := range pending {
+			publish()
+		}
+	}
+}
+
+// close stops the goroutine and discards the pending publishes.
+func (q *errorQueue) close() {
+	q.stop.Do(func() {
+		q.mu.Lock()
+		defer q.mu.Unlock()
+
+		close(q.done)
+		q.pending = nil
+	})
+}
@@ -0,0 +1,150 @@
+package hub
+
+import (
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandle(t *testing.T) {
+	h := New()
+	errs := h.Subscribe(10, ErrorTopic)
+	errFailed := errors.New("failed")
+
+	var processed int64
+
+	sub := h.Handle([]string{"job.*"}, func(msg Message) error {
+		atomic.AddInt64(&processed, 1)
+
+		switch msg.Name {
+		case "job.fail":
+			return errFailed
+		case "job.panic":
+			panic("boom")
+		}
+
+		return nil
+	}, HandlerWorkers(4), HandlerCapacity(10))
+
+	h.Publish(Message{Name: "job.ok"})
+	h.Publish(Message{Name: "job.fail"})
+	h.Publish(Message{Name: "job.panic"})
+	h.Publish(Message{Name: "job.ok"})
+
+	received := map[string]error{}
+
+	for i := 0; i < 2; i++ {
+		msg := <-errs.Receiver
+		require.Equal(t, []string{"job.*"}, msg.Fields["topic"])
+		received[msg.Fields["message"].(Message).Name] = msg.Fields["error"].(error)
+	}
+
+	require.Equal(t, errFailed, received["job.fail"])
+	require.EqualError(t, received["job.panic"], "hub: handler panic: boom")
+	require.Eventually(t, func() bool { return atomic.LoadInt64(&processed) == 4 }, time.Second, time.Millisecond)
+
+	h.Unsubscribe(sub)
+	h.Publish(Message{Name: "job.ok"})
+
+	time.Sleep(10 * time.Millisecond)
+	require.EqualValues(t, 4, atomic.LoadInt64(&processed))
+}
+
+func TestHandleWithErrorTopic(t *testing.T) {
+	h := New(WithErrorTopic("errors"))
+	errs := h.Subscribe(1, "errors")
+
+	h.Handle([]string{"job"}, func(msg Message) error {
+		return errors.New("failed")
+	})
+	h.Publish(Message{Name: "job"})
+
+	msg := <-errs.Receiver
+	require.EqualError(t, msg.Fields["error"].(error), "failed")
+	h.Close()
+}
+
+func TestHandleShouldNotBlockWhenTheHandlerReceivesTheErrorTopic(t *testing.T) {
+	h := New()
+	errs := h.NonBlockingSubscribe(10, ErrorTopic)
+
+	defer h.Close()
+
+	var processed int64
+
+	h.Handle([]string{"#"}, func(msg Message) error {
+		atomic.AddInt64(&processed, 1)
+		return errors.New("failed")
+	})
+
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+
+		h.Publish(Message{Name: "job"})
+		h.Publish(Message{Name: "job"})
+		h.Publish(Message{Name: ErrorTopic})
+		h.Publish(Message{Name: "job"})
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("the handler blocked on its own subscription")
+	}
+
+	require.Eventually(t, func() bool { return atomic.LoadInt64(&processed) == 4 }, time.Second, time.Millisecond)
+
+	errors := map[string]int{}
+
+	for i := 0; i < 4; i++ {
+		msg := <-errs.Receiver
+		if m, ok := msg.Fields["message"].(Message); ok {
+			errors[m.Name]++
+		} else {
+			errors[msg.Name]++
+		}
+	}
+
+	time.Sleep(10 * time.Millisecond)
+	require.Equal(t, map[string]int{"job": 3, ErrorTopic: 1}, errors)
+	require.Empty(t, errs.Receiver, "the error about the error topic message must be discarded")
+}
+
+func TestHandleShouldNotBlockWhenHandlersReceiveEachOtherErrors(t *testing.T) {
+	h := New()
+
+	defer h.Close()
+
+	var processed int64
+
+	for i := 0; i < 2; i++ {
+		h.Handle([]string{"#"}, func(Message) error {
+			atomic.AddInt64(&processed, 1)
+			return errors.New("failed")
+		})
+	}
+
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+
+		for i := 0; i < 10; i++ {
+			h.Publish(Message{Name: "job"})
+		}
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("the handlers blocked sending their errors to each other")
+	}
+
+	// each handler processes the 10 jobs and the 10 errors of the other handler.
+	require.Eventually(t, func() bool { return atomic.LoadInt64(&processed) == 40 }, time.Second, time.Millisecond)
+}
@@ -0,0 +1,104 @@
+package hub
+
+import "time"
+
+// SubscribeWithReplay create a blocking subscription like Subscribe which receives the history of the matching
+// topics, in the order they were published, before the new messages. The history is kept only if the
+// WithHistory option is used, without it the subscription only receives the retained messages.
+// While the history is delivered up to cap new messages are kept in memory and the next publishers wait for the
+// history to be delivered, so no message is lost or duplicated.
+func (h *Hub) SubscribeWithReplay(cap int, topics ...string) Subscription {
+	return h.register(topics, newBlockingSubscriber(cap), nil, true)
+}
+
+// History returns a copy of the messages kept in the history of the given topic.
+func (h *Hub) History(topic string) []Message {
+	h.store.mu.RLock()
+	defer h.store.mu.RUnlock()
+
+	msgs := messages(h.store.recent(h.store.history[topic], h.now()))
+	for i := range msgs {
+		msgs[i] = msgs[i].clone()
+	}
+
+	return msgs
+}
+
+// lookup returns the subscribers matching the message topic and add the message into the history.
+// The store is only locked if the history is enabled.
+func (h *Hub) lookup(m Message) []Subscriber {
+	if !h.store.recording() {
+		return mergeGroups(h.matcher.Lookup(m.Topic()))
+	}
+
+	h.store.mu.Lock()
+	defer h.store.mu.Unlock()
+
+	h.store.record(m, h.now())
+
+	return mergeGroups(h.matcher.Lookup(m.Topic()))
+}
+
+// recording returns true if the history is enabled.
+func (s *store) recording() bool {
+	return s.limit > 0 || s.age > 0
+}
+
+// record assigns the next sequence to the message and add it into the history of the topic,
+// removing the messages above the limit or older than the age.
+// The topics are swept once each age, so the expired topics are removed even if they are not published again.
+// This must be called with the lock held.
+func (s *store) record(m Message, now time.Time) storedMessage {
+	s.seq++
+	sm := storedMessage{msg: m, seq: s.seq, time: now}
+
+	if !s.recording() {
+		return sm
+	}
+
+	if s.age > 0 && now.Sub(s.swept) >= s.age {
+		s.sweep(now)
+	}
+
+	sms := s.recent(append(s.history[m.Topic()], sm), now)
+	if s.limit > 0 && len(sms) > s.limit {
+		sms = sms[len(sms)-s.limit:]
+	}
+
+	if len(sms) == 0 {
+		delete(s.history, m.Topic())
+	} else {
+		s.history[m.Topic()] = sms
+	}
+
+	return sm
+}
+
+// sweep removes the expired messages of all the topics and the topics left empty.
+// This must be called with the lock held.
+func (s *store) sweep(now time.Time) {
+	s.swept = now
+
+	for topic, sms := range s.history {
+		if sms = s.recent(sms, now); len(sms) == 0 {
+			delete(s.history, topic)
+		} else {
+			s.history[topic] = sms
+		}
+	}
+}
+
+// recent returns the messages newer than the age.
+func (s *store) recent(sms []storedMessage, now time.Time) []storedMessage {
+	if s.age <= 0 {
+		return sms
+	}
+
+	for i, sm := range sms {
+		if now.Sub(sm.time) <= s.age {
+			return sms[i:]
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,195 @@
+package hub
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSubscribeWithReplayShouldReceiveTheHistoryBeforeTheNewMessages(t *testing.T) {
+	h := New(WithHistory(3, 0))
+
+	defer h.Close()
+
+	for i := 1; i <= 5; i++ {
+		h.Publish(Message{Name: "a.b", Fields: Fields{"i": i}})
+		h.Publish(Message{Name: "a.c", Fields: Fields{"i": i * 10}})
+		h.Publish(Message{Name: "b.c", Fields: Fields{"i": i * 100}})
+	}
+
+	sub := h.SubscribeWithReplay(10, "a.*")
+	plain := h.Subscribe(10, "a.*")
+
+	defer h.Unsubscribe(sub)
+
+	h.Publish(Message{Name: "a.b", Fields: Fields{"i": 0}})
+
+	for _, i := range []int{3, 30, 4, 40, 5, 50, 0} {
+		require.Equal(t, i, (<-sub.Receiver).Fields["i"])
+	}
+
+	require.Equal(t, 0, (<-plain.Receiver).Fields["i"], "only the replay subscriptions receive the history")
+	require.Len(t, h.History("a.b"), 3)
+	require.Empty(t, h.History("d"))
+}
+
+func TestHistoryShouldRemoveTheOldMessages(t *testing.T) {
+	var (
+		mu  sync.Mutex
+		now = time.Now()
+	)
+
+	h := New(WithHistory(0, time.Second), WithClock(func() time.Time {
+		mu.Lock()
+		defer mu.Unlock()
+
+		return now
+	}))
+
+	defer h.Close()
+
+	advance := func(d time.Duration) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		now = now.Add(d)
+	}
+
+	h.Publish(Message{Name: "a", Fields: Fields{"i": 1}})
+	advance(time.Second)
+	h.Publish(Message{Name: "a", Fields: Fields{"i": 2}})
+	advance(500 * time.Millisecond)
+
+	history := h.History("a")
+	require.Len(t, history, 1)
+	require.Equal(t, 2, history[0].Fields["i"])
+
+	advance(time.Second)
+
+	sub := h.SubscribeWithReplay(10, "a")
+	h.Publish(Message{Name: "a", Fields: Fields{"i": 3}})
+	require.Equal(t, 3, (<-sub.Receiver).Fields["i"])
+}
+
+func TestHistoryShouldRemoveTheExpiredTopics(t *testing.T) {
+	now := time.Now()
+	h := New(WithHistory(0, time.Second), WithClock(func() time.Time { return now }))
+
+	defer h.Close()
+
+	for i := 0; i < 1000; i++ {
+		h.Publish(Message{Name: "order." + strconv.Itoa(i)})
+	}
+
+	require.Len(t, h.store.history, 1000)
+
+	now = now.Add(2 * time.Second)
+	h.Publish(Message{Name: "other"})
+
+	require.Len(t, h.store.history, 1, "the expired topics must be removed")
+	require.Empty(t, h.History("order.1"))
+}
+
+func TestSubscribeWithReplayShouldNotDuplicateRetainedMessages(t *testing.T) {
+	h := New(WithHistory(10, 0))
+
+	defer h.Close()
+
+	h.PublishRetained(Message{Name: "a", Fields: Fields{"i": 1}})
+	h.Publish(Message{Name: "a", Fields: Fields{"i": 2}})
+
+	sub := h.SubscribeWithReplay(10, "a")
+	h.Publish(Message{Name: "a", Fields: Fields{"i": 3}})
+
+	for _, i := range []int{1, 2, 3} {
+		require.Equal(t, i, (<-sub.Receiver).Fields["i"])
+	}
+
+	h.Unsubscribe(sub)
+
+	_, ok := <-sub.Receiver
+	require.False(t, ok)
+}
+
+func TestSubscribeWithReplayShouldKeepTheSubscriberBackpressure(t *testing.T) {
+	h := New(WithHistory(10, 0))
+
+	defer h.Close()
+
+	for i := 1; i <= 3; i++ {
+		h.Publish(Message{Name: "a", Fields: Fields{"i": i}})
+	}
+
+	sub := h.SubscribeWithReplay(1, "a")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	r, err := h.PublishContext(ctx, Message{Name: "a", Fields: Fields{"i": 4}})
+	require.NoError(t, err)
+	require.Equal(t, Report{Matched: 1, Delivered: 1}, r, "the messages up to the capacity are kept")
+
+	r, err = h.PublishContext(ctx, Message{Name: "a", Fields: Fields{"i": 5}})
+	require.Equal(t, context.DeadlineExceeded, err)
+	require.Equal(t, Report{Matched: 1, TimedOut: 1}, r, "the publisher must wait for the replay")
+
+	for _, i := range []int{1, 2, 3, 4} {
+		require.Equal(t, i, (<-sub.Receiver).Fields["i"])
+	}
+}
+
+func TestSubscribeWithReplayShouldNotLoseOrDuplicateMessages(t *testing.T) {
+	const count = 1000
+
+	h := New(WithHistory(count, 0))
+
+	defer h.Close()
+
+	var wg sync.WaitGroup
+
+	wg.Add(1)
+
+	go func() {
+		defer wg.Done()
+
+		for i := 1; i <= count; i++ {
+			h.Publish(Message{Name: "a.b", Fields: Fields{"i": i}})
+		}
+	}()
+
+	received := make([][]int, 20)
+
+	for n := range received {
+		n := n
+		sub := h.SubscribeWithReplay(0, "a.*")
+
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			for msg := range sub.Receiver {
+				i := msg.Fields["i"].(int)
+				received[n] = append(received[n], i)
+
+				if i == count {
+					return
+				}
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	for _, r := range received {
+		require.Len(t, r, count)
+
+		for i := range r {
+			require.Equal(t, i+1, r[i])
+		}
+	}
+}
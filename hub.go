@@ -1,49 +1,203 @@
 package hub
 
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
 // AlertTopic is used to notify when a nonblocking subscriber loose one message
 // You can subscribe on this topic and log or send metrics.
+// The topic can be changed using the WithAlertTopic option.
 const AlertTopic = "hub.subscription.messageslost"
 
 type (
 	// Hub is a component that provides publish and subscribe capabilities for messages.
 	// Every message has a Name used to route them to subscribers and this can be used like RabbitMQ topics exchanges.
-	// Where every word is separated by dots `.` and you can use `*` as a wildcard.
+	// Where every word is separated by dots `.` and you can use `*` as a wildcard for one word
+	// and `#` as a wildcard for zero or more words.
 	Hub struct {
-		matcher matcher
-		fields  Fields
+		matcher         Matcher
+		fields          Fields
+		delimiter       string
+		alertTopic      string
+		alertRate       int
+		alertEvery      time.Duration
+		alerts          *alerter
+		groups          *groups
+		errors          *errorQueue
+		watchers        *watchers
+		replays         *replays
+		ids             *uint64
+		store           *store
+		historyLimit    int
+		historyAge      time.Duration
+		errorTopic      string
+		deadLetterTopic string
+		deadLetterSink  func(DeadLetter)
+		codec           Codec
+		source          string
+		capacity        int
+		batchFlush      time.Duration
+		hooks           Hooks
+		now             func() time.Time
+	}
+
+	// watchers keeps the channels used to stop the goroutines removing the subscriptions when their
+	// context is done, so they don't leak when the subscriptions are removed by Unsubscribe or Close.
+	watchers struct {
+		mu   sync.Mutex
+		stop map[Subscriber]chan struct{}
+	}
+
+	// replays keeps the replaySubscribers used inside the matcher in place of the subscribers which are
+	// receiving stored messages, so the subscriptions keep the Subscriber given to the hub.
+	replays struct {
+		mu sync.Mutex
+		m  map[Subscriber]*replaySubscriber
+	}
+
+	// Report contains the result of a publish.
+	// Dropped counts the subscribers which lost the message because they are full or closed and TimedOut
+	// counts the subscribers which gave up waiting because of their timeout or the context.
+	Report struct {
+		Matched   int
+		Delivered int
+		Dropped   int
+		TimedOut  int
+	}
+
+	// Hooks are functions called by the Hub on every publish, subscribe and unsubscribe.
+	// Nil functions are ignored.
+	Hooks struct {
+		OnPublish     func(Message)
+		OnSubscribe   func(Subscription)
+		OnUnsubscribe func(Subscription)
 	}
 )
 
 // New create and return a new empty hub.
-func New() *Hub {
-	return &Hub{
-		matcher: newCSTrieMatcher(),
-		fields:  Fields{},
+// The hub behavior can be changed using the given options.
+func New(opts ...Option) *Hub {
+	h := &Hub{
+		fields:     Fields{},
+		delimiter:  delimiter,
+		alertTopic: AlertTopic,
+		alertRate:  defaultAlertRate,
+		alertEvery: defaultAlertInterval,
+		errorTopic: ErrorTopic,
+		capacity:   defaultCapacity,
+		batchFlush: defaultBatchFlushTimeout,
+		now:        time.Now,
+	}
+
+	for _, opt := range opts {
+		opt(h)
+	}
+
+	if h.matcher == nil {
+		h.matcher = newCSTrieMatcher(h.delimiter)
 	}
+
+	h.alerts = newAlerter(h.alertRate)
+	h.groups = newGroups()
+	h.errors = newErrorQueue()
+	h.watchers = &watchers{stop: make(map[Subscriber]chan struct{})}
+	h.replays = &replays{m: make(map[Subscriber]*replaySubscriber)}
+	h.ids = new(uint64)
+	h.store = newStore(h.historyLimit, h.historyAge)
+
+	return h
 }
 
 // Publish will send an event to all the subscribers matching the event name.
+// Messages rejected by the codec set with the WithCodec option are published on the error topic.
 func (h *Hub) Publish(m Message) {
+	h.publish(m, nil)
+}
+
+// publish sends the message to all the subscribers matching the event name except the given one.
+func (h *Hub) publish(m Message, except Subscriber) {
+	m, err := h.prepare(m)
+	if err != nil {
+		h.publishError(err, m, []string{m.Topic()}, nil)
+		return
+	}
+
+	for _, sub := range h.lookup(m) {
+		if sub != except {
+			sub.Set(m.clone())
+		}
+	}
+}
+
+// PublishContext will send an event to all the subscribers matching the event name and
+// returns a Report with the result of the deliveries.
+// Blocking subscribers stop waiting when the context is done and in this case the context error is returned.
+// Messages rejected by the codec set with the WithCodec option are not sent and the codec error is returned.
+func (h *Hub) PublishContext(ctx context.Context, m Message) (Report, error) {
+	m, err := h.prepare(m)
+	if err != nil {
+		return Report{}, err
+	}
+
+	subs := h.lookup(m)
+	r := Report{Matched: len(subs)}
+
+	for _, sub := range subs {
+		cs, ok := sub.(contextSubscriber)
+		if !ok {
+			sub.Set(m.clone())
+			r.Delivered++
+
+			continue
+		}
+
+		switch cs.SetContext(ctx, m.clone()) {
+		case delivered:
+			r.Delivered++
+		case dropped:
+			r.Dropped++
+		case timedOut:
+			r.TimedOut++
+		}
+	}
+
+	return r, ctx.Err()
+}
+
+// prepare copies the message fields, adds the hub fields and metadata, encodes the body and call the publish hook.
+// The caller can change its Fields after the publish without affecting the subscribers.
+func (h *Hub) prepare(m Message) (Message, error) {
 	if len(h.fields) > 0 && m.Fields == nil {
-		m.Fields = Fields{}
+		m.Fields = make(Fields, len(h.fields))
+	} else {
+		m.Fields = m.Fields.Clone()
 	}
 
 	for k, v := range h.fields {
 		m.Fields[k] = v
 	}
 
-	for _, sub := range h.matcher.Lookup(m.Topic()) {
-		sub.Set(m)
+	m, err := h.encode(h.stamp(m))
+	if err != nil {
+		return m, err
+	}
+
+	if h.hooks.OnPublish != nil {
+		h.hooks.OnPublish(m)
 	}
+
+	return m, nil
 }
 
 // With creates a child Hub with the fields added to it.
 // When someone call Publish, this Fields will be added automatically into the message.
 func (h *Hub) With(f Fields) *Hub {
-	hub := Hub{
-		matcher: h.matcher,
-		fields:  Fields{},
-	}
+	hub := *h
+	hub.fields = Fields{}
+
 	for k, v := range h.fields {
 		hub.fields[k] = v
 	}
@@ -59,46 +213,309 @@ func (h *Hub) With(f Fields) *Hub {
 // The cap param is used inside the subscriber and in this case used to create a channel.
 // cap(1) = unbuffered channel.
 func (h *Hub) Subscribe(cap int, topics ...string) Subscription {
-	return h.matcher.Subscribe(topics, newBlockingSubscriber(cap))
+	return h.subscribe(topics, newBlockingSubscriber(cap))
+}
+
+// SubscribeContext create a blocking subscription like Subscribe which is removed and closed when
+// the given context is done.
+func (h *Hub) SubscribeContext(ctx context.Context, cap int, topics ...string) Subscription {
+	return h.unsubscribeOnDone(ctx, h.Subscribe(cap, topics...))
+}
+
+// NonBlockingSubscribeContext create a nonblocking subscription like NonBlockingSubscribe which is removed
+// and closed when the given context is done.
+func (h *Hub) NonBlockingSubscribeContext(ctx context.Context, cap int, topics ...string) Subscription {
+	return h.unsubscribeOnDone(ctx, h.NonBlockingSubscribe(cap, topics...))
+}
+
+// TimeoutSubscribe create a blocking subscription to receive events for a given topic.
+// The publish side will block at most the given timeout waiting for the subscriber, after that the message
+// is lost and an alert is published with the time waited.
+func (h *Hub) TimeoutSubscribe(cap int, timeout time.Duration, topics ...string) Subscription {
+	lc := h.newLossCounter(topics)
+
+	return h.register(topics, newTimeoutSubscriber(cap, timeout, timeoutFunc(lc.timedOut)), lc, false)
 }
 
 // NonBlockingSubscribe create a nonblocking subscription to receive events for a given topic.
 // This subscriber will loose messages if the buffer reaches the max capability.
+// If cap <= 0 the default capacity is used.
 func (h *Hub) NonBlockingSubscribe(cap int, topics ...string) Subscription {
-	return h.matcher.Subscribe(
-		topics,
-		newNonBlockingSubscriber(
-			cap,
-			alertFunc(func(missed int) {
-				h.alert(missed, topics)
-			}),
-		))
+	lc := h.newLossCounter(topics)
+
+	return h.register(topics, newNonBlockingSubscriber(h.bufferCap(cap), alertFunc(lc.full)), lc, false)
+}
+
+// RingSubscribe create a nonblocking subscription to receive events for a given topic.
+// This subscriber keeps the latest messages, if the buffer reaches the max capability the oldest message is
+// evicted and an alert is published.
+// If cap <= 0 the default capacity is used.
+func (h *Hub) RingSubscribe(cap int, topics ...string) Subscription {
+	lc := h.newLossCounter(topics)
+
+	return h.register(topics, newRingSubscriber(h.bufferCap(cap), alertFunc(lc.evicted)), lc, false)
+}
+
+// ConflatingSubscribe create a nonblocking subscription to receive events for a given topic.
+// This subscriber only keeps the latest pending message for each value of the given Fields key, so a slow
+// consumer always receives the newest state. If the key is empty or not present in the message the topic is used.
+func (h *Hub) ConflatingSubscribe(key string, topics ...string) Subscription {
+	return h.subscribe(topics, newConflatingSubscriber(key))
+}
+
+// UnboundedSubscribe create a subscription to receive events for a given topic.
+// This subscriber will never block the publish side and will never loose messages, the messages are kept in an
+// unbounded queue until consumed. The returned QueueStats can be used to check the queue state and an alert is
+// published when the queue crosses one of the given high-water marks.
+func (h *Hub) UnboundedSubscribe(limits QueueLimits, topics ...string) (Subscription, QueueStats) {
+	s := newUnboundedSubscriber(limits, watermarkFunc(func(length, size int) {
+		h.alert(topics, Fields{"queued": length, "size": size})
+	}))
+
+	return h.subscribe(topics, s), s
+}
+
+// BatchSubscribe create a blocking subscription to receive events for a given topic in batches.
+// A batch is delivered when it reaches the given size or when its first message waited the given latency.
+// When the subscription is closed the current batch is delivered before closing the Batches channel, the
+// Batches channel must be drained to receive it or the batch is dropped after the timeout set with the
+// WithBatchFlushTimeout option.
+// If size <= 0 the default capacity is used and if latency <= 0 only full batches are delivered.
+func (h *Hub) BatchSubscribe(size int, latency time.Duration, topics ...string) BatchSubscription {
+	s := newBatchSubscriber(h.bufferCap(size), latency, h.batchFlush)
+
+	return BatchSubscription{
+		Subscription: h.subscribe(topics, s),
+		Batches:      s.Batches(),
+	}
+}
+
+// SubscribeWith create a subscription using the given Subscriber to receive events for a given topic.
+// This can be used to implement custom delivery strategies like persist the messages or forward them to a socket.
+func (h *Hub) SubscribeWith(sub Subscriber, topics ...string) Subscription {
+	return h.subscribe(topics, sub)
 }
 
 // Unsubscribe remove and close the Subscription.
 func (h *Hub) Unsubscribe(sub Subscription) {
-	h.matcher.Unsubscribe(sub)
+	h.watchers.release(sub.subscriber)
+	h.unsubscribe(sub)
+}
+
+// unsubscribe removes the subscription from the matcher or its groups and closes it.
+func (h *Hub) unsubscribe(sub Subscription) {
+	if !h.groups.leave(h.matcher, sub.subscriber) {
+		h.matcher.Unsubscribe(h.replays.remove(sub))
+	}
+
 	sub.subscriber.Close()
+
+	if h.hooks.OnUnsubscribe != nil {
+		h.hooks.OnUnsubscribe(sub)
+	}
 }
 
 // Close will unsubscribe all the subscriptions and close them all.
 func (h *Hub) Close() {
+	h.watchers.releaseAll()
+	h.errors.close()
+
 	subs := h.matcher.Subscriptions()
 	for _, s := range subs {
 		h.matcher.Unsubscribe(s)
 	}
 
-	for _, s := range subs {
+	h.replays.clear()
+
+	for _, s := range h.groups.clear() {
 		s.subscriber.Close()
+
+		if h.hooks.OnUnsubscribe != nil {
+			h.hooks.OnUnsubscribe(s)
+		}
+	}
+
+	for _, s := range bySubscriber(subs) {
+		s.subscriber.Close()
+
+		switch s.subscriber.(type) {
+		case *groupSubscriber, *probe:
+			continue
+		}
+
+		if h.hooks.OnUnsubscribe != nil {
+			h.hooks.OnUnsubscribe(s)
+		}
+	}
+}
+
+// bySubscriber merges the subscriptions of the same Subscriber, the matcher returns one for each topic.
+// The replaySubscribers are replaced by the Subscribers they wrap.
+func bySubscriber(subs []Subscription) []Subscription {
+	index := make(map[Subscriber]int, len(subs))
+	merged := make([]Subscription, 0, len(subs))
+
+	for _, s := range subs {
+		if r, ok := s.subscriber.(*replaySubscriber); ok {
+			s.subscriber = r.Subscriber
+		}
+
+		i, ok := index[s.subscriber]
+		if !ok {
+			index[s.subscriber] = len(merged)
+			merged = append(merged, NewSubscription(append([]string(nil), s.Topics...), s.subscriber))
+
+			continue
+		}
+
+		merged[i].Topics = append(merged[i].Topics, s.Topics...)
+	}
+
+	return merged
+}
+
+func (h *Hub) subscribe(topics []string, sub Subscriber) Subscription {
+	return h.register(topics, sub, nil, false)
+}
+
+// register adds the Subscriber to the matcher and sends it the retained messages, and the history if asked,
+// matching the topics. The lossCounter, if any, is bound to the Subscriber and the ID of the Subscription
+// before it can lose any message.
+func (h *Hub) register(topics []string, sub Subscriber, lc *lossCounter, history bool) Subscription {
+	id := h.nextID()
+	if lc != nil {
+		lc.sub = sub
+		lc.id = id
 	}
+
+	h.store.mu.RLock()
+
+	msgs := h.store.matching(h.matcher, topics, history, h.now())
+
+	var r *replaySubscriber
+	if len(msgs) > 0 {
+		r = newReplaySubscriber(sub)
+		sub = r
+	}
+
+	s := h.matcher.Subscribe(topics, sub)
+	h.store.mu.RUnlock()
+
+	if r != nil {
+		s = NewSubscription(s.Topics, h.replays.add(r))
+		go r.replay(msgs)
+	}
+
+	s.id = id
+
+	if h.hooks.OnSubscribe != nil {
+		h.hooks.OnSubscribe(s)
+	}
+
+	return s
+}
+
+// nextID returns a new Subscription ID.
+func (h *Hub) nextID() uint64 {
+	return atomic.AddUint64(h.ids, 1)
 }
 
-func (h *Hub) alert(missed int, topics []string) {
-	h.Publish(Message{
-		Name: AlertTopic,
-		Fields: Fields{
-			"missed": missed,
-			"topic":  topics,
-		},
-	})
+// unsubscribeOnDone removes the subscription when the context is done.
+func (h *Hub) unsubscribeOnDone(ctx context.Context, sub Subscription) Subscription {
+	if ctx.Done() == nil {
+		return sub
+	}
+
+	stop := h.watchers.watch(sub.subscriber)
+
+	go func() {
+		select {
+		case <-ctx.Done():
+			if h.watchers.release(sub.subscriber) {
+				h.unsubscribe(sub)
+			}
+		case <-stop:
+		}
+	}()
+
+	return sub
+}
+
+// add keeps the replaySubscriber and returns the Subscriber it wraps.
+func (r *replays) add(s *replaySubscriber) Subscriber {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.m[s.Subscriber] = s
+
+	return s.Subscriber
+}
+
+// remove forgets the replaySubscriber used in place of the subscription Subscriber and returns the
+// subscription used inside the matcher.
+func (r *replays) remove(sub Subscription) Subscription {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	s, ok := r.m[sub.subscriber]
+	if !ok {
+		return sub
+	}
+
+	delete(r.m, sub.subscriber)
+
+	return NewSubscription(sub.Topics, s)
+}
+
+// clear forgets all the replaySubscribers.
+func (r *replays) clear() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.m = make(map[Subscriber]*replaySubscriber)
+}
+
+// watch returns the channel closed when the subscriber is released.
+func (w *watchers) watch(sub Subscriber) <-chan struct{} {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	stop := make(chan struct{})
+	w.stop[sub] = stop
+
+	return stop
+}
+
+// release stops the watcher of the subscriber and returns false if it's not watched.
+func (w *watchers) release(sub Subscriber) bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	stop, ok := w.stop[sub]
+	if ok {
+		close(stop)
+		delete(w.stop, sub)
+	}
+
+	return ok
+}
+
+// releaseAll stops all the watchers.
+func (w *watchers) releaseAll() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	for sub, stop := range w.stop {
+		close(stop)
+		delete(w.stop, sub)
+	}
+}
+
+// bufferCap returns the given capacity or the default capacity if cap <= 0.
+func (h *Hub) bufferCap(cap int) int {
+	if cap <= 0 {
+		return h.capacity
+	}
+
+	return cap
 }
@@ -15,8 +15,16 @@ func BenchmarkPublishOnBlockingSubscribers(b *testing.B) {
 	runBenchmark(b, 100, 4, 30, true)
 }
 
-func runBenchmark(b *testing.B, numItems, numThreads, numSubscribers int, blocking bool) {
-	h := New()
+func BenchmarkPublishOnNonBlockingSubscribersListMatcher(b *testing.B) {
+	runBenchmark(b, 100, 4, 30, false, WithMatcher(newListMatcher()))
+}
+
+func BenchmarkPublishOnBlockingSubscribersListMatcher(b *testing.B) {
+	runBenchmark(b, 100, 4, 30, true, WithMatcher(newListMatcher()))
+}
+
+func runBenchmark(b *testing.B, numItems, numThreads, numSubscribers int, blocking bool, opts ...Option) {
+	h := New(opts...)
 	subs := createSubscribers(h, numSubscribers, blocking)
 	itemsToInsert := generateTopics(numThreads, numItems)
 	var wgPub, wgSub sync.WaitGroup
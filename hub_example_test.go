@@ -52,3 +52,35 @@ func ExampleHub() {
 	// receive msg with topic account.changepassword.failed and id 456
 	// receive msg with topic account.login.success and id 123
 }
+
+func ExampleHub_Handle() {
+	h := hub.New()
+	var wg sync.WaitGroup
+
+	wg.Add(2)
+	// the handler is executed by one goroutine managed by the hub.
+	h.Handle([]string{"account.login.*"}, func(msg hub.Message) error {
+		defer wg.Done()
+		fmt.Printf("receive msg with topic %s and id %d\n", msg.Name, msg.Fields["id"])
+
+		return nil
+	})
+
+	h.Publish(hub.Message{
+		Name:   "account.login.failed",
+		Fields: hub.Fields{"id": 123},
+	})
+	h.Publish(hub.Message{
+		Name:   "account.login.success",
+		Fields: hub.Fields{"id": 123},
+	})
+
+	// wait until all the messages are processed
+	wg.Wait()
+	// close all the subscribers and stop the handlers
+	h.Close()
+
+	// Output:
+	// receive msg with topic account.login.failed and id 123
+	// receive msg with topic account.login.success and id 123
+}
@@ -1,10 +1,13 @@
 package hub
 
 import (
+	"context"
+	"sync"
 	"sync/atomic"
 	"testing"
 	"time"
 
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
 
@@ -68,6 +71,39 @@ func TestHub(t *testing.T) {
 			subFN:         func(h *Hub) Subscription { return h.NonBlockingSubscribe(-1, "trade") },
 			ExpectedCount: 1,
 		},
+		{
+			name:          "ring subscription buffered",
+			messages:      defaultMessages,
+			subFN:         func(h *Hub) Subscription { return h.RingSubscribe(2, "trade.*") },
+			ExpectedCount: 3,
+		},
+		{
+			name:          "ring subscription with an invalid cap buffer",
+			messages:      defaultMessages,
+			subFN:         func(h *Hub) Subscription { return h.RingSubscribe(-1, "forex") },
+			ExpectedCount: 1,
+		},
+		{
+			name:          "timeout subscription",
+			messages:      defaultMessages,
+			subFN:         func(h *Hub) Subscription { return h.TimeoutSubscribe(0, time.Second, "forex.*") },
+			ExpectedCount: 3,
+		},
+		{
+			name:          "conflating subscription",
+			messages:      defaultMessages,
+			subFN:         func(h *Hub) Subscription { return h.ConflatingSubscribe("", "*.eur", "*.jpy") },
+			ExpectedCount: 4,
+		},
+		{
+			name:     "unbounded subscription",
+			messages: defaultMessages,
+			subFN: func(h *Hub) Subscription {
+				sub, _ := h.UnboundedSubscribe(QueueLimits{}, "trade", "trade.*")
+				return sub
+			},
+			ExpectedCount: 4,
+		},
 		{
 			name:          "get all the messages",
 			messages:      defaultMessages,
@@ -117,6 +153,282 @@ func TestNonBlockingSubscriberShouldAlertIfLoseMessages(t *testing.T) {
 	require.Equal(t, []string{"a.*.c"}, msg.Fields["topic"])
 }
 
+func TestTimeoutSubscriberShouldAlertIfLoseMessages(t *testing.T) {
+	h := New()
+	sub := h.TimeoutSubscribe(1, 10*time.Millisecond, "a.*")
+
+	defer h.Unsubscribe(sub)
+
+	subsAlert := h.NonBlockingSubscribe(10, AlertTopic)
+	// send messages without a working subscriber
+	for i := 0; i < 3; i++ {
+		h.Publish(Message{Name: "a.b", Fields: Fields{"i": i}})
+	}
+
+	require.Equal(t, 0, (<-sub.Receiver).Fields["i"])
+
+	msg := <-subsAlert.Receiver
+	require.Equal(t, 2, msg.Fields["missed"])
+	require.Equal(t, []string{"a.*"}, msg.Fields["topic"])
+	require.GreaterOrEqual(t, int64(msg.Fields["waited"].(time.Duration)), int64(20*time.Millisecond))
+}
+
+func TestRingSubscriberShouldEvictTheOldestMessages(t *testing.T) {
+	h := New()
+	sub := h.RingSubscribe(2, "a.*")
+
+	defer h.Unsubscribe(sub)
+
+	subsAlert := h.NonBlockingSubscribe(10, AlertTopic)
+	// send messages without a working subscriber
+	for i := 0; i < 5; i++ {
+		h.Publish(Message{Name: "a.b", Fields: Fields{"i": i}})
+	}
+
+	require.Equal(t, 3, (<-sub.Receiver).Fields["i"])
+	require.Equal(t, 4, (<-sub.Receiver).Fields["i"])
+
+	msg := <-subsAlert.Receiver
+	require.Equal(t, 3, msg.Fields["missed"])
+	require.Equal(t, []string{"a.*"}, msg.Fields["topic"])
+}
+
+func TestConflatingSubscriberShouldKeepTheLatestMessagePerKey(t *testing.T) {
+	tests := []struct {
+		name string
+		key  string
+		msgs func(i int) []Message
+	}{
+		{
+			name: "by topic",
+			msgs: func(i int) []Message {
+				return []Message{{Name: "price.eur", Fields: Fields{"i": i}}, {Name: "price.usd", Fields: Fields{"i": i}}}
+			},
+		},
+		{
+			name: "by field",
+			key:  "id",
+			msgs: func(i int) []Message {
+				return []Message{
+					{Name: "price.update", Fields: Fields{"id": "eur", "i": i}},
+					{Name: "price.update", Fields: Fields{"id": "usd", "i": i}},
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			h := New()
+			sub := h.ConflatingSubscribe(tt.key, "price.*")
+
+			defer h.Unsubscribe(sub)
+
+			for i := 0; i < 10; i++ {
+				for _, m := range tt.msgs(i) {
+					h.Publish(m)
+				}
+			}
+
+			// one message can be in flight before the conflation.
+			received := 0
+			for latest := 0; latest < 2; {
+				msg := <-sub.Receiver
+				received++
+
+				if msg.Fields["i"] == 9 {
+					latest++
+				}
+			}
+
+			require.LessOrEqual(t, received, 3)
+		})
+	}
+}
+
+func TestUnboundedSubscriberShouldAlertOnHighWaterMarks(t *testing.T) {
+	h := New()
+	sub, stats := h.UnboundedSubscribe(QueueLimits{Len: 10}, "a.*")
+
+	defer h.Unsubscribe(sub)
+
+	subsAlert := h.NonBlockingSubscribe(10, AlertTopic)
+	// send messages without a working subscriber
+	newMessage := func(i int) Message {
+		return Message{Name: "a.b", Fields: Fields{"i": i}, ID: "id", Source: "source", CorrelationID: "correlation"}
+	}
+
+	for i := 0; i < 100; i++ {
+		h.Publish(newMessage(i))
+	}
+
+	require.Eventually(t, func() bool { return stats.Len() == 99 }, time.Second, time.Millisecond)
+	require.Equal(t, 99*messageSize(newMessage(0)), stats.Size(), "the metadata must be included in the size")
+
+	msg := <-subsAlert.Receiver
+	require.Equal(t, 11, msg.Fields["queued"])
+	require.Equal(t, []string{"a.*"}, msg.Fields["topic"])
+
+	for i := 0; i < 100; i++ {
+		require.Equal(t, i, (<-sub.Receiver).Fields["i"])
+	}
+
+	require.Equal(t, 0, stats.Len())
+	require.Equal(t, 0, stats.Size())
+	require.Len(t, subsAlert.Receiver, 0, "only one alert should be published while above the marks")
+
+	// after the queue was drained the alert is armed again.
+	for i := 0; i < 12; i++ {
+		h.Publish(Message{Name: "a.b"})
+	}
+
+	msg = <-subsAlert.Receiver
+	require.Equal(t, 11, msg.Fields["queued"])
+}
+
+func TestBatchSubscriberShouldDeliverBatchesBySizeOrLatency(t *testing.T) {
+	h := New()
+	sub := h.BatchSubscribe(3, 50*time.Millisecond, "a.*")
+
+	go func() {
+		for i := 0; i < 7; i++ {
+			h.Publish(Message{Name: "a.b", Fields: Fields{"i": i}})
+		}
+	}()
+
+	require.Len(t, <-sub.Batches, 3)
+	require.Len(t, <-sub.Batches, 3)
+
+	start := time.Now()
+	batch := <-sub.Batches
+
+	require.GreaterOrEqual(t, int64(time.Since(start)), int64(40*time.Millisecond))
+	require.Equal(t, []Message{{Name: "a.b", Fields: Fields{"i": 6}}}, withoutMetadata(batch...))
+
+	h.Unsubscribe(sub.Subscription)
+
+	_, ok := <-sub.Batches
+	require.False(t, ok)
+}
+
+func TestBatchSubscriberShouldFlushOnClose(t *testing.T) {
+	h := New()
+	sub := h.BatchSubscribe(10, 0, "a.*")
+
+	h.Publish(Message{Name: "a.b"})
+	h.Publish(Message{Name: "a.c"})
+	h.Close()
+
+	require.Equal(t, []Message{{Name: "a.b"}, {Name: "a.c"}}, withoutMetadata(<-sub.Batches...))
+
+	_, ok := <-sub.Batches
+	require.False(t, ok)
+}
+
+func TestBatchSubscriberShouldNotWaitForeverOnClose(t *testing.T) {
+	h := New(WithBatchFlushTimeout(10 * time.Millisecond))
+	sub := h.BatchSubscribe(1, 0, "a")
+
+	h.Publish(Message{Name: "a"})
+	h.Close()
+
+	time.Sleep(50 * time.Millisecond)
+
+	_, ok := <-sub.Batches
+	require.False(t, ok, "the last batch must be dropped without a consumer")
+}
+
+func TestBatchSubscriberShouldWaitTheConsumerWithoutFlushTimeout(t *testing.T) {
+	h := New(WithBatchFlushTimeout(0))
+	sub := h.BatchSubscribe(1, 0, "a")
+
+	h.Publish(Message{Name: "a"})
+	h.Close()
+
+	time.Sleep(50 * time.Millisecond)
+
+	require.Len(t, <-sub.Batches, 1)
+
+	_, ok := <-sub.Batches
+	require.False(t, ok)
+}
+
+func TestSubscribeContextShouldUnsubscribeWhenTheContextIsDone(t *testing.T) {
+	tests := []struct {
+		name  string
+		subFN func(ctx context.Context, h *Hub) Subscription
+	}{
+		{
+			name:  "blocking",
+			subFN: func(ctx context.Context, h *Hub) Subscription { return h.SubscribeContext(ctx, 1, "a.*") },
+		},
+		{
+			name:  "non blocking",
+			subFN: func(ctx context.Context, h *Hub) Subscription { return h.NonBlockingSubscribeContext(ctx, 1, "a.*") },
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			h := New()
+			ctx, cancel := context.WithCancel(context.Background())
+			sub := tt.subFN(ctx, h)
+
+			h.Publish(Message{Name: "a.b"})
+			require.Equal(t, "a.b", (<-sub.Receiver).Name)
+
+			cancel()
+
+			_, ok := <-sub.Receiver
+			require.False(t, ok, "the channel should be closed after the context is done")
+			require.Len(t, h.matcher.Subscriptions(), 0)
+		})
+	}
+}
+
+func TestSubscribeContextShouldStopWatchingRemovedSubscriptions(t *testing.T) {
+	var unsubscribed int64
+
+	h := New(WithHooks(Hooks{OnUnsubscribe: func(Subscription) { atomic.AddInt64(&unsubscribed, 1) }}))
+	ctx, cancel := context.WithCancel(context.Background())
+
+	h.Unsubscribe(h.SubscribeContext(ctx, 1, "a"))
+	h.NonBlockingSubscribeContext(ctx, 1, "b")
+	h.Close()
+	require.Empty(t, h.watchers.stop, "the watchers must be stopped")
+
+	cancel()
+	time.Sleep(10 * time.Millisecond)
+	require.Equal(t, int64(2), atomic.LoadInt64(&unsubscribed), "the subscriptions must be removed only once")
+}
+
+func TestPublishContextShouldReportTheDeliveries(t *testing.T) {
+	h := New()
+	h.Subscribe(1, "a.*")
+	h.NonBlockingSubscribe(1, "a.*")
+	h.TimeoutSubscribe(0, 10*time.Millisecond, "a.*")
+	h.SubscribeWith(&sliceSubscriber{}, "a.*")
+
+	defer h.Close()
+
+	r, err := h.PublishContext(context.Background(), Message{Name: "a.b"})
+	require.NoError(t, err)
+	require.Equal(t, Report{Matched: 4, Delivered: 3, TimedOut: 1}, r)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	r, err = h.PublishContext(ctx, Message{Name: "a.b"})
+	require.Equal(t, context.DeadlineExceeded, err)
+	require.Equal(t, Report{Matched: 4, Delivered: 1, Dropped: 1, TimedOut: 2}, r)
+
+	r, err = h.PublishContext(ctx, Message{Name: "b"})
+	require.Equal(t, context.DeadlineExceeded, err)
+	require.Equal(t, Report{}, r)
+}
+
 func TestWith(t *testing.T) {
 	h := New()
 	subH1 := h.With(Fields{"hub": "subH1", "something": 123})
@@ -195,6 +507,69 @@ func testPublishDoesNotPanicWhenSubscriberClosesAfterLookup(t *testing.T, subscr
 	}
 }
 
+func TestUnsubscribeDoesNotDeadlockWhenSubscriberIsFull(t *testing.T) {
+	tests := []struct {
+		name  string
+		subFN func(h *Hub) Subscription
+	}{
+		{
+			name:  "blocking",
+			subFN: func(h *Hub) Subscription { return h.Subscribe(0, "a") },
+		},
+		{
+			name:  "timeout",
+			subFN: func(h *Hub) Subscription { return h.TimeoutSubscribe(0, time.Hour, "a") },
+		},
+		{
+			name:  "batch",
+			subFN: func(h *Hub) Subscription { return h.BatchSubscribe(1, 0, "a").Subscription },
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			for _, closeFN := range []func(h *Hub, sub Subscription){
+				func(h *Hub, sub Subscription) { h.Unsubscribe(sub) },
+				func(h *Hub, sub Subscription) { h.Close() },
+			} {
+				h := New()
+				sub := tt.subFN(h)
+				published := make(chan struct{})
+
+				// nobody is consuming so the publishers will block.
+				go func() {
+					for i := 0; i < 3; i++ {
+						h.Publish(Message{Name: "a"})
+					}
+
+					close(published)
+				}()
+
+				time.Sleep(10 * time.Millisecond)
+
+				closed := make(chan struct{})
+				go func() {
+					closeFN(h, sub)
+					close(closed)
+				}()
+
+				select {
+				case <-closed:
+				case <-time.After(time.Second):
+					require.FailNow(t, "closing the subscription should not wait for the consumer")
+				}
+
+				select {
+				case <-published:
+				case <-time.After(time.Second):
+					require.FailNow(t, "closing the subscription should release the blocked publishers")
+				}
+			}
+		})
+	}
+}
+
 func TestPublishInitializesNilMessageFieldsWhenHubHasFields(t *testing.T) {
 	h := New().With(Fields{"source": "test"})
 
@@ -209,6 +584,86 @@ func TestPublishInitializesNilMessageFieldsWhenHubHasFields(t *testing.T) {
 	require.Equal(t, Fields{"source": "test"}, msg.Fields)
 }
 
+func TestWithMatcher(t *testing.T) {
+	m := newListMatcher()
+	h := New(WithMatcher(m))
+
+	sub := h.Subscribe(2, "account.*", "trade.#")
+	require.Len(t, m.Subscriptions(), 1)
+
+	h.Publish(Message{Name: "account.login"})
+	h.Publish(Message{Name: "forex.eur"})
+	h.Publish(Message{Name: "trade.eur.buy"})
+
+	require.Equal(t, "account.login", (<-sub.Receiver).Name)
+	require.Equal(t, "trade.eur.buy", (<-sub.Receiver).Name)
+
+	h.Unsubscribe(sub)
+	require.Len(t, m.Subscriptions(), 0)
+}
+
+type sliceSubscriber struct {
+	mu   sync.Mutex
+	msgs []Message
+}
+
+func (s *sliceSubscriber) Set(msg Message) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.msgs = append(s.msgs, msg)
+}
+
+func (s *sliceSubscriber) Ch() <-chan Message { return nil }
+func (s *sliceSubscriber) Close()             {}
+
+func TestSubscribeWith(t *testing.T) {
+	h := New()
+	s := &sliceSubscriber{}
+	sub := h.SubscribeWith(s, "account.*")
+	require.Equal(t, Subscriber(s), sub.Subscriber())
+
+	h.Publish(Message{Name: "account.login"})
+	h.Publish(Message{Name: "forex.eur"})
+	h.Publish(Message{Name: "account.logout"})
+	h.Unsubscribe(sub)
+	h.Publish(Message{Name: "account.login"})
+
+	require.Equal(t, []Message{{Name: "account.login"}, {Name: "account.logout"}}, withoutMetadata(s.msgs...))
+}
+
+func TestSubscribeWithShouldKeepTheSubscriberWithRetainedMessages(t *testing.T) {
+	for name, h := range map[string]*Hub{"cstrie": New(), "custom matcher": New(WithMatcher(newListMatcher()))} {
+		h := h
+
+		t.Run(name, func(t *testing.T) {
+			s := &sliceSubscriber{}
+
+			h.PublishRetained(Message{Name: "account.login"})
+
+			sub := h.SubscribeWith(s, "account.*")
+			require.Equal(t, Subscriber(s), sub.Subscriber())
+
+			h.Publish(Message{Name: "account.logout"})
+			require.Eventually(t, func() bool {
+				s.mu.Lock()
+				defer s.mu.Unlock()
+
+				return len(s.msgs) == 2
+			}, time.Second, time.Millisecond)
+
+			h.Unsubscribe(sub)
+			h.Publish(Message{Name: "account.login"})
+			require.Len(t, h.matcher.Subscriptions(), 0)
+
+			s.mu.Lock()
+			defer s.mu.Unlock()
+
+			require.Equal(t, []Message{{Name: "account.login"}, {Name: "account.logout"}}, withoutMetadata(s.msgs...))
+		})
+	}
+}
+
 func newMessageCounter(s Subscription) *messageCounter {
 	ms := &messageCounter{sub: s, c: 0}
 	go func(ms *messageCounter) {
@@ -227,3 +682,56 @@ func (ms *messageCounter) count() int64 {
 func (ms *messageCounter) reset() {
 	atomic.StoreInt64(&ms.c, int64(0))
 }
+
+func TestSubscribersShouldReceiveTheirOwnFields(t *testing.T) {
+	h := New(WithHistory(10, 0)).With(Fields{"hub": 1})
+
+	defer h.Close()
+
+	var wg sync.WaitGroup
+
+	for i := 0; i < 10; i++ {
+		sub := h.Subscribe(10, "a")
+		i := i
+
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			for n := 0; n < 10; n++ {
+				msg := <-sub.Receiver
+				assert.Equal(t, n, msg.Fields["n"])
+				assert.Equal(t, 1, msg.Fields["hub"])
+
+				msg.Fields["n"] = i
+				msg.Fields["sub"] = i
+				delete(msg.Fields, "hub")
+			}
+		}()
+	}
+
+	for n := 0; n < 10; n++ {
+		f := Fields{"n": n}
+		h.Publish(Message{Name: "a", Fields: f})
+
+		f["n"] = -1
+		f["publisher"] = true
+	}
+
+	wg.Wait()
+
+	for n, msg := range h.History("a") {
+		require.Equal(t, Fields{"n": n, "hub": 1}, msg.Fields)
+		msg.Fields["n"] = -1
+	}
+
+	h.PublishRetained(Message{Name: "b", Fields: Fields{"n": 1}})
+
+	retained, _ := h.Retained("b")
+	retained.Fields["n"] = -1
+
+	retained, _ = h.Retained("b")
+	require.Equal(t, Fields{"n": 1, "hub": 1}, retained.Fields, "the retained message must not be changed")
+	require.Equal(t, 0, h.History("a")[0].Fields["n"], "the history must not be changed")
+}
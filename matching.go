@@ -16,8 +16,9 @@
 package hub
 
 const (
-	delimiter = "."
-	wildcard  = "*"
+	delimiter     = "."
+	wildcard      = "*"
+	multiWildcard = "#"
 )
 
 type (
@@ -25,13 +26,17 @@ type (
 	Subscription struct {
 		Topics     []string
 		Receiver   <-chan Message
-		subscriber subscriber
+		subscriber Subscriber
+		id         uint64
 	}
 
-	// subscriber is the interface used internally to send values and get the channel used by subscribers.
-	// This is used to override the behavior of channel and support nonBlocking operations
-	subscriber interface {
+	// Subscriber is the interface used to send values and get the channel used by subscriptions.
+	// This is used to override the behavior of channel and support nonBlocking operations.
+	// Custom implementations can be used with Hub.SubscribeWith.
+	Subscriber interface {
 		// Set send the given Event to be processed by the subscriber
+		// This func is called by every Publish, possibly from different goroutines, and MUST NOT panic
+		// when called after Close.
 		Set(Message)
 		// Ch return the channel used to consume messages inside the subscription.
 		// This func MUST always return the same channel.
@@ -39,20 +44,42 @@ type (
 		// Close will close the internal state and the subscriber will not receive more messages
 		// WARN: This function can be executed more than one time so the code MUST take care of this situation and
 		// avoid problems like close a closed channel.
+		// Close MUST NOT wait for the consumer, Set calls blocked waiting for it must be interrupted.
 		Close()
 	}
 )
 
-// matcher contains topic subscriptions and performs matches on them.
-type matcher interface {
+// Matcher contains topic subscriptions and performs matches on them.
+// The Hub uses the CSTrie matcher by default but any implementation can be used with the WithMatcher option.
+// All the methods MUST be safe for concurrent use.
+type Matcher interface {
 	// Subscribe adds the Subscriber to the topics and returns a Subscription.
-	Subscribe(topics []string, sub subscriber) Subscription
+	Subscribe(topics []string, sub Subscriber) Subscription
 
 	// Unsubscribe removes the Subscription.
 	Unsubscribe(sub Subscription)
 
 	// Lookup returns the subscribers for the given topic.
-	Lookup(topic string) []subscriber
+	Lookup(topic string) []Subscriber
 
+	// Subscriptions returns all the Subscriptions.
 	Subscriptions() []Subscription
 }
+
+// NewSubscription returns a Subscription for the given topics and Subscriber.
+// This should be used by Matcher implementations to create the Subscriptions returned from Subscribe.
+func NewSubscription(topics []string, sub Subscriber) Subscription {
+	return Subscription{Topics: topics, Receiver: sub.Ch(), subscriber: sub}
+}
+
+// ID returns the identifier assigned by the hub when the Subscription was created, it's used by the alerts and
+// the dead letters to identify the Subscription which lost the messages. The Subscriptions created by a Matcher
+// without the hub have no ID.
+func (s Subscription) ID() uint64 {
+	return s.id
+}
+
+// Subscriber returns the Subscriber used by this Subscription.
+func (s Subscription) Subscriber() Subscriber {
+	return s.subscriber
+}
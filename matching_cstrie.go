@@ -35,11 +35,11 @@ type cNode struct {
 }
 
 // newCNode creates a new C-node with the given subscription path.
-func newCNode(words []string, sub subscriber) *cNode {
+func newCNode(words []string, sub Subscriber) *cNode {
 	if len(words) == 1 {
 		return &cNode{
 			branches: map[string]*branch{
-				words[0]: {subs: map[subscriber]struct{}{sub: {}}},
+				words[0]: {subs: map[Subscriber]struct{}{sub: {}}},
 			},
 		}
 	}
@@ -48,14 +48,14 @@ func newCNode(words []string, sub subscriber) *cNode {
 
 	return &cNode{
 		branches: map[string]*branch{
-			words[0]: {subs: map[subscriber]struct{}{}, iNode: nin},
+			words[0]: {subs: map[Subscriber]struct{}{}, iNode: nin},
 		},
 	}
 }
 
 // inserted returns a copy of this C-node with the specified subscriber
 // inserted.
-func (c *cNode) inserted(words []string, sub subscriber) *cNode {
+func (c *cNode) inserted(words []string, sub Subscriber) *cNode {
 	branches := make(map[string]*branch, len(c.branches)+1)
 	for key, branch := range c.branches {
 		branches[key] = branch
@@ -63,10 +63,10 @@ func (c *cNode) inserted(words []string, sub subscriber) *cNode {
 
 	var br *branch
 	if len(words) == 1 {
-		br = &branch{subs: map[subscriber]struct{}{sub: {}}}
+		br = &branch{subs: map[Subscriber]struct{}{sub: {}}}
 	} else {
 		br = &branch{
-			subs:  make(map[subscriber]struct{}),
+			subs:  make(map[Subscriber]struct{}),
 			iNode: &iNode{main: &mainNode{cNode: newCNode(words[1:], sub)}},
 		}
 	}
@@ -77,13 +77,13 @@ func (c *cNode) inserted(words []string, sub subscriber) *cNode {
 }
 
 // updated returns a copy of this C-node with the specified branch updated.
-func (c *cNode) updated(word string, sub subscriber) *cNode {
+func (c *cNode) updated(word string, sub Subscriber) *cNode {
 	branches := make(map[string]*branch, len(c.branches))
 	for word, branch := range c.branches {
 		branches[word] = branch
 	}
 
-	newBranch := &branch{subs: map[subscriber]struct{}{sub: {}}}
+	newBranch := &branch{subs: map[Subscriber]struct{}{sub: {}}}
 	br, ok := branches[word]
 
 	if ok {
@@ -114,7 +114,7 @@ func (c *cNode) updatedBranch(word string, in *iNode, br *branch) *cNode {
 
 // removed returns a copy of this C-node with the subscriber removed from the
 // corresponding branch.
-func (c *cNode) removed(word string, sub subscriber) *cNode {
+func (c *cNode) removed(word string, sub Subscriber) *cNode {
 	branches := make(map[string]*branch, len(c.branches))
 	for word, branch := range c.branches {
 		branches[word] = branch
@@ -135,20 +135,20 @@ func (c *cNode) removed(word string, sub subscriber) *cNode {
 	return &cNode{branches: branches}
 }
 
-// getBranches returns the branches for the given word. There are two possible
-// branches: exact match and single wildcard.
-func (c *cNode) getBranches(word string) (*branch, *branch) {
-	return c.branches[word], c.branches[wildcard]
+// getBranches returns the branches for the given word. There are three possible
+// branches: exact match, single wildcard and multi wildcard.
+func (c *cNode) getBranches(word string) (*branch, *branch, *branch) {
+	return c.branches[word], c.branches[wildcard], c.branches[multiWildcard]
 }
 
 type branch struct {
 	iNode *iNode
-	subs  map[subscriber]struct{}
+	subs  map[Subscriber]struct{}
 }
 
 // updated returns a copy of this branch updated with the given I-node.
 func (b *branch) updated(in *iNode) *branch {
-	subs := make(map[subscriber]struct{}, len(b.subs))
+	subs := make(map[Subscriber]struct{}, len(b.subs))
 	for id, sub := range b.subs {
 		subs[id] = sub
 	}
@@ -157,8 +157,8 @@ func (b *branch) updated(in *iNode) *branch {
 }
 
 // removed returns a copy of this branch with the given subscriber removed.
-func (b *branch) removed(sub subscriber) *branch {
-	subs := make(map[subscriber]struct{}, len(b.subs))
+func (b *branch) removed(sub Subscriber) *branch {
+	subs := make(map[Subscriber]struct{}, len(b.subs))
 	for id, sub := range b.subs {
 		subs[id] = sub
 	}
@@ -169,8 +169,8 @@ func (b *branch) removed(sub subscriber) *branch {
 }
 
 // subscribers returns the Subscribers for this branch.
-func (b *branch) subscribers() []subscriber {
-	subs := make([]subscriber, len(b.subs))
+func (b *branch) subscribers() []Subscriber {
+	subs := make([]Subscriber, len(b.subs))
 	i := 0
 
 	for sub := range b.subs {
@@ -184,32 +184,33 @@ func (b *branch) subscribers() []subscriber {
 type tNode struct{}
 
 type csTrieMatcher struct {
-	root *iNode
+	root      *iNode
+	delimiter string
 }
 
-func newCSTrieMatcher() matcher {
+func newCSTrieMatcher(delimiter string) Matcher {
 	root := &iNode{main: &mainNode{cNode: &cNode{}}}
-	return &csTrieMatcher{root: root}
+	return &csTrieMatcher{root: root, delimiter: delimiter}
 }
 
 // Subscribe adds the subscriber to the topic and returns a Subscription.
-func (c *csTrieMatcher) Subscribe(topics []string, sub subscriber) Subscription {
+func (c *csTrieMatcher) Subscribe(topics []string, sub Subscriber) Subscription {
 	var (
 		rootPtr = (*unsafe.Pointer)(unsafe.Pointer(&c.root))
 		root    = (*iNode)(atomic.LoadPointer(rootPtr))
 	)
 
 	for _, topic := range topics {
-		words := strings.Split(topic, delimiter)
+		words := strings.Split(topic, c.delimiter)
 		if !c.iinsert(root, nil, words, sub) {
 			return c.Subscribe(topics, sub)
 		}
 	}
 
-	return Subscription{Topics: topics, Receiver: sub.Ch(), subscriber: sub}
+	return NewSubscription(topics, sub)
 }
 
-func (c *csTrieMatcher) iinsert(i, parent *iNode, words []string, sub subscriber) bool {
+func (c *csTrieMatcher) iinsert(i, parent *iNode, words []string, sub Subscriber) bool {
 	// Linearization point.
 	mainPtr := (*unsafe.Pointer)(unsafe.Pointer(&i.main))
 	main := (*mainNode)(atomic.LoadPointer(mainPtr))
@@ -271,14 +272,14 @@ func (c *csTrieMatcher) Unsubscribe(sub Subscription) {
 	)
 
 	for _, topic := range sub.Topics {
-		words := strings.Split(topic, delimiter)
+		words := strings.Split(topic, c.delimiter)
 		if !c.iremove(root, nil, nil, words, 0, sub.subscriber) {
 			c.Unsubscribe(sub)
 		}
 	}
 }
 
-func (c *csTrieMatcher) iremove(i, parent, parentsParent *iNode, words []string, wordIdx int, sub subscriber) bool {
+func (c *csTrieMatcher) iremove(i, parent, parentsParent *iNode, words []string, wordIdx int, sub Subscriber) bool {
 	// Linearization point.
 	mainPtr := (*unsafe.Pointer)(unsafe.Pointer(&i.main))
 	main := (*mainNode)(atomic.LoadPointer(mainPtr))
@@ -342,9 +343,9 @@ func (c *csTrieMatcher) iremove(i, parent, parentsParent *iNode, words []string,
 }
 
 // Lookup returns the Subscribers for the given topic.
-func (c *csTrieMatcher) Lookup(topic string) []subscriber {
+func (c *csTrieMatcher) Lookup(topic string) []Subscriber {
 	var (
-		words   = strings.Split(topic, delimiter)
+		words   = strings.Split(topic, c.delimiter)
 		rootPtr = (*unsafe.Pointer)(unsafe.Pointer(&c.root))
 		root    = (*iNode)(atomic.LoadPointer(rootPtr))
 	)
@@ -360,19 +361,24 @@ func (c *csTrieMatcher) Lookup(topic string) []subscriber {
 // ilookup attempts to retrieve the Subscribers for the word path. True is
 // returned if the Subscribers were retrieved, false if the operation needs to
 // be retried.
-func (c *csTrieMatcher) ilookup(i, parent *iNode, words []string) ([]subscriber, bool) {
+func (c *csTrieMatcher) ilookup(i, parent *iNode, words []string) ([]Subscriber, bool) {
 	// Linearization point.
 	mainPtr := (*unsafe.Pointer)(unsafe.Pointer(&i.main))
 	main := (*mainNode)(atomic.LoadPointer(mainPtr))
 
 	switch {
 	case main.cNode != nil:
-		// Traverse exact-match branch and single-word-wildcard branch.
-		exact, singleWC := main.cNode.getBranches(words[0])
-		subs := make(map[subscriber]struct{})
+		// Traverse exact-match, single-word-wildcard and multi-word-wildcard
+		// branches.
+		exact, singleWC, multiWC := main.cNode.getBranches(words[0])
+		subs := make(map[Subscriber]struct{})
+
+		for _, br := range []*branch{exact, singleWC} {
+			if br == nil {
+				continue
+			}
 
-		if exact != nil {
-			s, ok := c.bLookup(i, exact, words)
+			s, ok := c.bLookup(i, br, words)
 			if !ok {
 				return nil, false
 			}
@@ -382,8 +388,8 @@ func (c *csTrieMatcher) ilookup(i, parent *iNode, words []string) ([]subscriber,
 			}
 		}
 
-		if singleWC != nil {
-			s, ok := c.bLookup(i, singleWC, words)
+		if multiWC != nil {
+			s, ok := c.mLookup(i, multiWC, words)
 			if !ok {
 				return nil, false
 			}
@@ -393,7 +399,7 @@ func (c *csTrieMatcher) ilookup(i, parent *iNode, words []string) ([]subscriber,
 			}
 		}
 
-		s := make([]subscriber, len(subs))
+		s := make([]Subscriber, len(subs))
 		i := 0
 
 		for sub := range subs {
@@ -413,21 +419,100 @@ func (c *csTrieMatcher) ilookup(i, parent *iNode, words []string) ([]subscriber,
 // bLookup attempts to retrieve the Subscribers from the word path along the
 // given branch. True is returned if the Subscribers were retrieved, false if
 // the operation needs to be retried.
-func (c *csTrieMatcher) bLookup(i *iNode, b *branch, words []string) ([]subscriber, bool) {
+func (c *csTrieMatcher) bLookup(i *iNode, b *branch, words []string) ([]Subscriber, bool) {
 	if len(words) > 1 {
 		// If more than 1 key is present in the path, the tree must be
 		// traversed deeper.
 		if b.iNode == nil {
 			// If the branch doesn't point to an I-node, no subscribers
 			// exist.
-			return make([]subscriber, 0), true
+			return make([]Subscriber, 0), true
 		}
 		// If the branch has an I-node, ilookup is called recursively.
 		return c.ilookup(b.iNode, i, words[1:])
 	}
 
-	// Retrieve the subscribers from the branch.
-	return b.subscribers(), true
+	// Retrieve the subscribers from the branch and the ones subscribed with a
+	// trailing multi wildcard, which also matches zero words.
+	subs := b.subscribers()
+	if b.iNode == nil {
+		return subs, true
+	}
+
+	s, ok := c.eLookup(b.iNode, i)
+	if !ok {
+		return nil, false
+	}
+
+	return append(subs, s...), true
+}
+
+// mLookup attempts to retrieve the Subscribers from the word path along the
+// given multi wildcard branch. The multi wildcard can consume zero or more
+// words, so every suffix of the path is looked up below the branch. True is
+// returned if the Subscribers were retrieved, false if the operation needs to
+// be retried.
+func (c *csTrieMatcher) mLookup(i *iNode, b *branch, words []string) ([]Subscriber, bool) {
+	// The wildcard consumes all the remaining words.
+	subs := b.subscribers()
+	if b.iNode == nil {
+		return subs, true
+	}
+
+	s, ok := c.eLookup(b.iNode, i)
+	if !ok {
+		return nil, false
+	}
+
+	subs = append(subs, s...)
+
+	// The wildcard consumes the first n words and the rest of the path is
+	// matched below the branch.
+	for n := 0; n < len(words); n++ {
+		s, ok := c.ilookup(b.iNode, i, words[n:])
+		if !ok {
+			return nil, false
+		}
+
+		subs = append(subs, s...)
+	}
+
+	return subs, true
+}
+
+// eLookup attempts to retrieve the Subscribers matching an empty word path
+// below the given I-node. Only multi wildcard branches can match zero words.
+// True is returned if the Subscribers were retrieved, false if the operation
+// needs to be retried.
+func (c *csTrieMatcher) eLookup(i, parent *iNode) ([]Subscriber, bool) {
+	// Linearization point.
+	mainPtr := (*unsafe.Pointer)(unsafe.Pointer(&i.main))
+	main := (*mainNode)(atomic.LoadPointer(mainPtr))
+
+	switch {
+	case main.cNode != nil:
+		br := main.cNode.branches[multiWildcard]
+		if br == nil {
+			return nil, true
+		}
+
+		subs := br.subscribers()
+		if br.iNode == nil {
+			return subs, true
+		}
+
+		s, ok := c.eLookup(br.iNode, i)
+		if !ok {
+			return nil, false
+		}
+
+		return append(subs, s...), true
+	case main.tNode != nil:
+		clean(parent)
+		return nil, false
+	default:
+		panic("csTrie is in an invalid state")
+	}
 }
 
 // Subscriptions return all the subscriptions inside the cstrie.
@@ -469,11 +554,7 @@ func (c *csTrieMatcher) isubscriptions(i, parent *iNode, words []string) ([]Subs
 			}
 
 			for s := range br.subs {
-				subs = append(subs, Subscription{
-					Topics:     []string{strings.Join(cwords, delimiter)},
-					subscriber: s,
-					Receiver:   s.Ch(),
-				})
+				subs = append(subs, NewSubscription([]string{strings.Join(cwords, c.delimiter)}, s))
 			}
 		}
 
@@ -24,7 +24,7 @@ import (
 func TestCSTrieMatcher(t *testing.T) {
 	assert := assert.New(t)
 	var (
-		m  = newCSTrieMatcher()
+		m  = newCSTrieMatcher(delimiter)
 		s0 = discardSubscriber(0)
 		s1 = discardSubscriber(1)
 		s2 = discardSubscriber(2)
@@ -39,11 +39,11 @@ func TestCSTrieMatcher(t *testing.T) {
 	sub6 := m.Subscribe([]string{"*"}, s2)
 	assert.Len(m.Subscriptions(), 7)
 
-	assertEqual(assert, []subscriber{s0, s1}, m.Lookup("forex.eur"))
-	assertEqual(assert, []subscriber{s2}, m.Lookup("forex"))
-	assertEqual(assert, []subscriber{}, m.Lookup("trade.jpy"))
-	assertEqual(assert, []subscriber{s0, s1}, m.Lookup("forex.jpy"))
-	assertEqual(assert, []subscriber{s1, s2}, m.Lookup("trade"))
+	assertEqual(assert, []Subscriber{s0, s1}, m.Lookup("forex.eur"))
+	assertEqual(assert, []Subscriber{s2}, m.Lookup("forex"))
+	assertEqual(assert, []Subscriber{}, m.Lookup("trade.jpy"))
+	assertEqual(assert, []Subscriber{s0, s1}, m.Lookup("forex.jpy"))
+	assertEqual(assert, []Subscriber{s1, s2}, m.Lookup("trade"))
 
 	m.Unsubscribe(sub0)
 	m.Unsubscribe(sub1)
@@ -53,16 +53,52 @@ func TestCSTrieMatcher(t *testing.T) {
 	m.Unsubscribe(sub5)
 	m.Unsubscribe(sub6)
 
-	assertEqual(assert, []subscriber{}, m.Lookup("forex.eur"))
-	assertEqual(assert, []subscriber{}, m.Lookup("forex"))
-	assertEqual(assert, []subscriber{}, m.Lookup("trade.jpy"))
-	assertEqual(assert, []subscriber{}, m.Lookup("forex.jpy"))
-	assertEqual(assert, []subscriber{}, m.Lookup("trade"))
+	assertEqual(assert, []Subscriber{}, m.Lookup("forex.eur"))
+	assertEqual(assert, []Subscriber{}, m.Lookup("forex"))
+	assertEqual(assert, []Subscriber{}, m.Lookup("trade.jpy"))
+	assertEqual(assert, []Subscriber{}, m.Lookup("forex.jpy"))
+	assertEqual(assert, []Subscriber{}, m.Lookup("trade"))
+}
+
+func TestCSTrieMatcherMultiWildcard(t *testing.T) {
+	assert := assert.New(t)
+	var (
+		m  = newCSTrieMatcher(delimiter)
+		s0 = discardSubscriber(0)
+		s1 = discardSubscriber(1)
+		s2 = discardSubscriber(2)
+		s3 = discardSubscriber(3)
+	)
+
+	sub0 := m.Subscribe([]string{"account.#"}, s0)
+	sub1 := m.Subscribe([]string{"#"}, s1)
+	sub2 := m.Subscribe([]string{"account.#.failed"}, s2)
+	sub3 := m.Subscribe([]string{"*.#.*"}, s3)
+	assert.Len(m.Subscriptions(), 4)
+
+	assertEqual(assert, []Subscriber{s0, s1}, m.Lookup("account"))
+	assertEqual(assert, []Subscriber{s0, s1, s2, s3}, m.Lookup("account.failed"))
+	assertEqual(assert, []Subscriber{s0, s1, s3}, m.Lookup("account.login"))
+	assertEqual(assert, []Subscriber{s0, s1, s2, s3}, m.Lookup("account.login.failed"))
+	assertEqual(assert, []Subscriber{s0, s1, s2, s3}, m.Lookup("account.login.password.failed"))
+	assertEqual(assert, []Subscriber{s1, s3}, m.Lookup("trade.login.failed"))
+	assertEqual(assert, []Subscriber{s1}, m.Lookup("trade"))
+
+	m.Unsubscribe(sub0)
+	assertEqual(assert, []Subscriber{s1, s2, s3}, m.Lookup("account.login.failed"))
+
+	m.Unsubscribe(sub1)
+	m.Unsubscribe(sub2)
+	m.Unsubscribe(sub3)
+	assert.Len(m.Subscriptions(), 0)
+
+	assertEqual(assert, []Subscriber{}, m.Lookup("account"))
+	assertEqual(assert, []Subscriber{}, m.Lookup("account.login.failed"))
 }
 
 func BenchmarkCSTrieMatcherSubscribe(b *testing.B) {
 	var (
-		m  = newCSTrieMatcher()
+		m  = newCSTrieMatcher(delimiter)
 		s0 = discardSubscriber(0)
 	)
 
@@ -77,7 +113,7 @@ func BenchmarkCSTrieMatcherSubscribe(b *testing.B) {
 
 func BenchmarkCSTrieMatcherUnsubscribe(b *testing.B) {
 	var (
-		m  = newCSTrieMatcher()
+		m  = newCSTrieMatcher(delimiter)
 		s0 = discardSubscriber(0)
 		id = m.Subscribe([]string{"foo.*.baz.qux.quux"}, s0)
 	)
@@ -92,7 +128,7 @@ func BenchmarkCSTrieMatcherUnsubscribe(b *testing.B) {
 
 func BenchmarkCSTrieMatcherLookup(b *testing.B) {
 	var (
-		m  = newCSTrieMatcher()
+		m  = newCSTrieMatcher(delimiter)
 		s0 = discardSubscriber(0)
 	)
 
@@ -107,7 +143,7 @@ func BenchmarkCSTrieMatcherLookup(b *testing.B) {
 
 func BenchmarkCSTrieMatcherSubscribeCold(b *testing.B) {
 	var (
-		m  = newCSTrieMatcher()
+		m  = newCSTrieMatcher(delimiter)
 		s0 = discardSubscriber(0)
 	)
 
@@ -120,7 +156,7 @@ func BenchmarkCSTrieMatcherSubscribeCold(b *testing.B) {
 
 func BenchmarkCSTrieMatcherUnsubscribeCold(b *testing.B) {
 	var (
-		m  = newCSTrieMatcher()
+		m  = newCSTrieMatcher(delimiter)
 		s0 = discardSubscriber(0)
 		id = m.Subscribe([]string{"foo.*.baz.qux.quux"}, s0)
 	)
@@ -134,7 +170,7 @@ func BenchmarkCSTrieMatcherUnsubscribeCold(b *testing.B) {
 
 func BenchmarkCSTrieMatcherLookupCold(b *testing.B) {
 	var (
-		m  = newCSTrieMatcher()
+		m  = newCSTrieMatcher(delimiter)
 		s0 = discardSubscriber(0)
 	)
 
@@ -148,60 +184,60 @@ func BenchmarkCSTrieMatcherLookupCold(b *testing.B) {
 
 func BenchmarkMultithreaded1Thread5050CSTrie(b *testing.B) {
 	numThreads := 1
-	benchmarkMatcher(b, numThreads, newCSTrieMatcher(), percentual5050)
+	benchmarkMatcher(b, numThreads, newCSTrieMatcher(delimiter), percentual5050)
 }
 
 func BenchmarkMultithreaded2Thread5050CSTrie(b *testing.B) {
 	numThreads := 2
-	benchmarkMatcher(b, numThreads, newCSTrieMatcher(), percentual5050)
+	benchmarkMatcher(b, numThreads, newCSTrieMatcher(delimiter), percentual5050)
 }
 
 func BenchmarkMultithreaded4Thread5050CSTrie(b *testing.B) {
 	numThreads := 4
-	benchmarkMatcher(b, numThreads, newCSTrieMatcher(), percentual5050)
+	benchmarkMatcher(b, numThreads, newCSTrieMatcher(delimiter), percentual5050)
 }
 
 func BenchmarkMultithreaded8Thread5050CSTrie(b *testing.B) {
 	numThreads := 8
-	benchmarkMatcher(b, numThreads, newCSTrieMatcher(), percentual5050)
+	benchmarkMatcher(b, numThreads, newCSTrieMatcher(delimiter), percentual5050)
 }
 
 func BenchmarkMultithreaded12Thread5050CSTrie(b *testing.B) {
 	numThreads := 12
-	benchmarkMatcher(b, numThreads, newCSTrieMatcher(), percentual5050)
+	benchmarkMatcher(b, numThreads, newCSTrieMatcher(delimiter), percentual5050)
 }
 
 func BenchmarkMultithreaded16Thread5050CSTrie(b *testing.B) {
 	numThreads := 16
-	benchmarkMatcher(b, numThreads, newCSTrieMatcher(), percentual5050)
+	benchmarkMatcher(b, numThreads, newCSTrieMatcher(delimiter), percentual5050)
 }
 
 func BenchmarkMultithreaded1Thread9010CSTrie(b *testing.B) {
 	numThreads := 1
-	benchmarkMatcher(b, numThreads, newCSTrieMatcher(), percentual9010)
+	benchmarkMatcher(b, numThreads, newCSTrieMatcher(delimiter), percentual9010)
 }
 
 func BenchmarkMultithreaded2Thread9010CSTrie(b *testing.B) {
 	numThreads := 2
-	benchmarkMatcher(b, numThreads, newCSTrieMatcher(), percentual9010)
+	benchmarkMatcher(b, numThreads, newCSTrieMatcher(delimiter), percentual9010)
 }
 
 func BenchmarkMultithreaded4Thread9010CSTrie(b *testing.B) {
 	numThreads := 4
-	benchmarkMatcher(b, numThreads, newCSTrieMatcher(), percentual9010)
+	benchmarkMatcher(b, numThreads, newCSTrieMatcher(delimiter), percentual9010)
 }
 
 func BenchmarkMultithreaded8Thread9010CSTrie(b *testing.B) {
 	numThreads := 8
-	benchmarkMatcher(b, numThreads, newCSTrieMatcher(), percentual9010)
+	benchmarkMatcher(b, numThreads, newCSTrieMatcher(delimiter), percentual9010)
 }
 
 func BenchmarkMultithreaded12Thread9010CSTrie(b *testing.B) {
 	numThreads := 12
-	benchmarkMatcher(b, numThreads, newCSTrieMatcher(), percentual9010)
+	benchmarkMatcher(b, numThreads, newCSTrieMatcher(delimiter), percentual9010)
 }
 
 func BenchmarkMultithreaded16Thread9010CSTrie(b *testing.B) {
 	numThreads := 16
-	benchmarkMatcher(b, numThreads, newCSTrieMatcher(), percentual9010)
+	benchmarkMatcher(b, numThreads, newCSTrieMatcher(delimiter), percentual9010)
 }
@@ -4,18 +4,30 @@ import (
 	"fmt"
 	"sort"
 	"strings"
+	"time"
 )
 
 type (
 	// Fields is a [key]value storage for Messages values.
+	// Every subscriber receives its own copy of the Fields, so it can be changed without affecting the other
+	// subscribers. The copy is shallow, values like maps, slices and pointers are still shared.
 	Fields map[string]interface{}
 
 	// Message represent some message/event passed into the hub
 	// It also contain some helper functions to convert the fields to primitive types.
+	// The ContentType identifies the Codec used to encode the Body.
+	// The ID, Timestamp and Source are set by the hub when the message is published and the CorrelationID and
+	// CausationID can be used to track the messages published because of other messages, see CausedBy.
 	Message struct {
-		Name   string
-		Body   []byte
-		Fields Fields
+		Name          string
+		Body          []byte
+		ContentType   string
+		Fields        Fields
+		ID            string
+		Timestamp     time.Time
+		Source        string
+		CorrelationID string
+		CausationID   string
 	}
 )
 
@@ -24,6 +36,27 @@ func (m *Message) Topic() string {
 	return m.Name
 }
 
+// clone returns the message with a copy of the Fields.
+func (m Message) clone() Message {
+	m.Fields = m.Fields.Clone()
+
+	return m
+}
+
+// Clone returns a shallow copy of the Fields.
+func (f Fields) Clone() Fields {
+	if f == nil {
+		return nil
+	}
+
+	c := make(Fields, len(f))
+	for k, v := range f {
+		c[k] = v
+	}
+
+	return c
+}
+
 func (f Fields) String() string {
 	if len(f) == 0 {
 		return "Fields(<empty>)"
@@ -1,6 +1,10 @@
 package hub
 
-import "testing"
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
 
 func TestFields_String(t *testing.T) {
 	tests := []struct {
@@ -21,3 +25,15 @@ func TestFields_String(t *testing.T) {
 		})
 	}
 }
+
+func TestFields_Clone(t *testing.T) {
+	require.Nil(t, Fields(nil).Clone())
+
+	f := Fields{"foo": "bar"}
+	c := f.Clone()
+	c["foo"] = "baz"
+	c["new"] = 1
+
+	require.Equal(t, Fields{"foo": "bar"}, f)
+	require.Equal(t, Fields{"foo": "baz", "new": 1}, c)
+}
@@ -0,0 +1,66 @@
+package hub
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"strconv"
+	"sync/atomic"
+)
+
+var (
+	// idPrefix is a random prefix which makes the IDs unique between processes.
+	idPrefix = newIDPrefix()
+	// idCounter makes the IDs unique inside the process.
+	idCounter uint64
+)
+
+// newIDPrefix returns the random prefix used by the message IDs.
+func newIDPrefix() string {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		panic("hub: cannot generate the message id prefix: " + err.Error())
+	}
+
+	return hex.EncodeToString(b)
+}
+
+// newID returns an unique message ID.
+func newID() string {
+	return idPrefix + "-" + strconv.FormatUint(atomic.AddUint64(&idCounter, 1), 36)
+}
+
+// CausedBy returns the message marked as caused by the given message.
+// The CausationID is set to the parent ID and the CorrelationID is copied from the parent, so all the messages
+// of one flow share the CorrelationID of the first message.
+func (m Message) CausedBy(parent Message) Message {
+	m.CausationID = parent.ID
+	m.CorrelationID = parent.CorrelationID
+
+	if m.CorrelationID == "" {
+		m.CorrelationID = parent.ID
+	}
+
+	return m
+}
+
+// stamp sets the metadata not provided by the publisher.
+// The CorrelationID defaults to the message ID, so the first message of a flow starts the correlation.
+func (h *Hub) stamp(m Message) Message {
+	if m.ID == "" {
+		m.ID = newID()
+	}
+
+	if m.Timestamp.IsZero() {
+		m.Timestamp = h.now()
+	}
+
+	if m.Source == "" {
+		m.Source = h.source
+	}
+
+	if m.CorrelationID == "" {
+		m.CorrelationID = m.ID
+	}
+
+	return m
+}
@@ -0,0 +1,118 @@
+package hub
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPublishShouldSetTheMetadata(t *testing.T) {
+	now := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	h := New(WithSource("billing"), WithClock(func() time.Time { return now }))
+	child := h.With(Fields{"ID": "field", "Source": "field"})
+	sub := h.Subscribe(10, "a")
+
+	defer h.Close()
+
+	h.Publish(Message{Name: "a"})
+	child.Publish(Message{Name: "a"})
+
+	given := Message{
+		Name:          "a",
+		ID:            "id",
+		Timestamp:     now.Add(-time.Hour),
+		Source:        "other",
+		CorrelationID: "correlation",
+		CausationID:   "causation",
+	}
+	h.Publish(given)
+
+	first := <-sub.Receiver
+	require.NotEmpty(t, first.ID)
+	require.Equal(t, now, first.Timestamp)
+	require.Equal(t, "billing", first.Source)
+	require.Equal(t, first.ID, first.CorrelationID)
+	require.Empty(t, first.CausationID)
+
+	second := <-sub.Receiver
+	require.NotEqual(t, first.ID, second.ID)
+	require.Equal(t, "billing", second.Source, "the hub fields must not change the metadata")
+	require.Equal(t, Fields{"ID": "field", "Source": "field"}, second.Fields)
+
+	require.Equal(t, given, <-sub.Receiver, "the metadata given by the publisher must be kept")
+}
+
+func TestMetadataShouldBeReceivedByAllSubscriberTypes(t *testing.T) {
+	h := New()
+
+	defer h.Close()
+
+	unbounded, _ := h.UnboundedSubscribe(QueueLimits{}, "a")
+	batch := h.BatchSubscribe(1, 0, "a")
+	subs := []Subscription{
+		h.Subscribe(1, "a"),
+		h.NonBlockingSubscribe(1, "a"),
+		h.TimeoutSubscribe(1, time.Second, "a"),
+		h.RingSubscribe(1, "a"),
+		h.ConflatingSubscribe("", "a"),
+		h.SubscribeGroup("group", 1, "a"),
+		h.SubscribePartitioned("partitioned", "", 1, "a"),
+		h.SubscribeWithReplay(1, "a"),
+		unbounded,
+	}
+
+	h.Publish(Message{Name: "a"})
+
+	b := <-batch.Batches
+	require.Len(t, b, 1)
+
+	id := b[0].ID
+	require.NotEmpty(t, id)
+
+	for _, sub := range subs {
+		msg := <-sub.Receiver
+		require.Equal(t, id, msg.ID)
+		require.Equal(t, id, msg.CorrelationID)
+		require.False(t, msg.Timestamp.IsZero())
+	}
+}
+
+func TestCausedByShouldTrackTheFlow(t *testing.T) {
+	h := New()
+	sub := h.Subscribe(10, "order.*")
+
+	defer h.Close()
+
+	h.Publish(Message{Name: "order.created"})
+	created := <-sub.Receiver
+
+	h.Publish(Message{Name: "order.paid"}.CausedBy(created))
+	paid := <-sub.Receiver
+
+	require.Equal(t, created.ID, paid.CausationID)
+	require.Equal(t, created.ID, paid.CorrelationID)
+
+	h.Publish(Message{Name: "order.shipped"}.CausedBy(paid))
+	shipped := <-sub.Receiver
+
+	require.Equal(t, paid.ID, shipped.CausationID)
+	require.Equal(t, created.ID, shipped.CorrelationID, "all the messages of the flow share the correlation")
+}
+
+func TestHandlerErrorsShouldBeCausedByTheMessage(t *testing.T) {
+	h := New()
+	errs := h.Subscribe(10, ErrorTopic)
+	published := h.Subscribe(10, "job")
+
+	defer h.Close()
+
+	h.Handle([]string{"job"}, func(Message) error { return errors.New("failed") })
+	h.Publish(Message{Name: "job"})
+
+	job := <-published.Receiver
+	msg := <-errs.Receiver
+	require.Equal(t, job.ID, msg.CausationID)
+	require.Equal(t, job.CorrelationID, msg.CorrelationID)
+}
@@ -0,0 +1,136 @@
+package hub
+
+import "time"
+
+// Option is used to change the default behavior of the Hub.
+type Option func(*Hub)
+
+// WithMatcher sets the Matcher used to route the messages to the subscribers.
+// The default Matcher is the CSTrie.
+func WithMatcher(m Matcher) Option {
+	return func(h *Hub) {
+		h.matcher = m
+	}
+}
+
+// WithDelimiter sets the delimiter used to split the topics into words.
+// The default delimiter is `.`. This option is only used by the default Matcher.
+func WithDelimiter(d string) Option {
+	return func(h *Hub) {
+		h.delimiter = d
+	}
+}
+
+// WithAlertTopic sets the topic used to publish the alerts about lost messages.
+// The default topic is AlertTopic.
+func WithAlertTopic(topic string) Option {
+	return func(h *Hub) {
+		h.alertTopic = topic
+	}
+}
+
+// WithAlertRate sets the max number of alerts published per second, the alerts above the rate are dropped
+// and counted by Hub.AlertsDropped. The default rate is 100 and rate <= 0 disables the limit.
+func WithAlertRate(rate int) Option {
+	return func(h *Hub) {
+		h.alertRate = rate
+	}
+}
+
+// WithAlertInterval sets the interval used to aggregate the messages lost by one subscription, only one alert
+// is published per subscription and interval. The default interval is one second and interval <= 0 publishes
+// one alert per lost message.
+func WithAlertInterval(interval time.Duration) Option {
+	return func(h *Hub) {
+		h.alertEvery = interval
+	}
+}
+
+// WithErrorTopic sets the topic used to publish the errors returned by handlers.
+// The default topic is ErrorTopic.
+func WithErrorTopic(topic string) Option {
+	return func(h *Hub) {
+		h.errorTopic = topic
+	}
+}
+
+// WithDeadLetterTopic sets the topic used to republish the messages lost by the subscriptions.
+// The lost message is sent in the "message" field with the subscription "topic" and "subscriber",
+// the "reason" and the "time" of the loss. By default the lost messages are discarded.
+func WithDeadLetterTopic(topic string) Option {
+	return func(h *Hub) {
+		h.deadLetterTopic = topic
+	}
+}
+
+// WithDeadLetterSink sets a function called with every message lost by the subscriptions.
+// The function is called by the publishing goroutine so it must not block.
+func WithDeadLetterSink(sink func(DeadLetter)) Option {
+	return func(h *Hub) {
+		h.deadLetterSink = sink
+	}
+}
+
+// WithDefaultCapacity sets the capacity used by nonblocking subscriptions when the cap param is <= 0.
+// The default capacity is 10.
+func WithDefaultCapacity(cap int) Option {
+	return func(h *Hub) {
+		if cap > 0 {
+			h.capacity = cap
+		}
+	}
+}
+
+// WithBatchFlushTimeout sets the time the last batch of a closed BatchSubscribe subscription waits for the
+// consumer before being dropped. The default timeout is one second and timeout <= 0 waits until the batch is
+// received, so the Batches channel must always be drained.
+func WithBatchFlushTimeout(timeout time.Duration) Option {
+	return func(h *Hub) {
+		h.batchFlush = timeout
+	}
+}
+
+// WithHooks sets the functions called on every publish, subscribe and unsubscribe.
+func WithHooks(hooks Hooks) Option {
+	return func(h *Hub) {
+		h.hooks = hooks
+	}
+}
+
+// WithClock sets the function used by the Hub to get the current time.
+// The default clock is time.Now.
+func WithClock(now func() time.Time) Option {
+	return func(h *Hub) {
+		h.now = now
+	}
+}
+
+// WithHistory keeps the last messages of each topic to be replayed by SubscribeWithReplay.
+// At most limit messages newer than age are kept per topic, limit <= 0 or age <= 0 removes that bound.
+// If both are <= 0 the history is disabled, which is the default.
+// With an age the expired topics are removed, so the history keeps only the topics published in the last two ages.
+// Without it the last messages of every topic ever published are kept, so use an age with many distinct topics.
+func WithHistory(limit int, age time.Duration) Option {
+	return func(h *Hub) {
+		h.historyLimit = limit
+		h.historyAge = age
+	}
+}
+
+// WithCodec sets the Codec used to encode the ValueField into the Body of the messages published without one.
+// Messages published with a Body and without ContentType are marked with the Codec content type and the bodies
+// are validated if the Codec, or the one registered for the message ContentType, implements Validator.
+// Messages with a ContentType without a registered Codec are rejected. By default the messages are not changed.
+func WithCodec(c Codec) Option {
+	return func(h *Hub) {
+		h.codec = c
+	}
+}
+
+// WithSource sets the Source of the messages published without one, like the name of the service.
+// By default the Source is empty.
+func WithSource(source string) Option {
+	return func(h *Hub) {
+		h.source = source
+	}
+}
@@ -0,0 +1,89 @@
+package hub
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithDelimiter(t *testing.T) {
+	h := New(WithDelimiter("/"))
+	sub := h.Subscribe(2, "account/*")
+
+	h.Publish(Message{Name: "account.login"})
+	h.Publish(Message{Name: "account/login"})
+	h.Close()
+
+	msg := <-sub.Receiver
+	require.Equal(t, "account/login", msg.Name)
+
+	_, ok := <-sub.Receiver
+	require.False(t, ok)
+}
+
+func TestWithAlertTopicAndClock(t *testing.T) {
+	now := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	h := New(
+		WithAlertTopic("alerts"),
+		WithClock(func() time.Time { return now }),
+	)
+	sub := h.NonBlockingSubscribe(1, "a")
+	alerts := h.Subscribe(1, "alerts")
+
+	h.Publish(Message{Name: "a"})
+	h.Publish(Message{Name: "a"})
+
+	msg := <-alerts.Receiver
+	require.Equal(t, 1, msg.Fields["missed"])
+	require.Equal(t, now, msg.Fields["time"])
+
+	h.Unsubscribe(sub)
+}
+
+func TestWithDefaultCapacity(t *testing.T) {
+	h := New(WithDefaultCapacity(3))
+	sub := h.NonBlockingSubscribe(0, "a")
+	require.Equal(t, 3, cap(sub.Receiver))
+
+	sub = New(WithDefaultCapacity(-1)).NonBlockingSubscribe(0, "a")
+	require.Equal(t, defaultCapacity, cap(sub.Receiver))
+}
+
+func TestWithHooks(t *testing.T) {
+	var published, subscribed, unsubscribed []string
+
+	h := New(WithHooks(Hooks{
+		OnPublish:     func(m Message) { published = append(published, m.Name) },
+		OnSubscribe:   func(s Subscription) { subscribed = append(subscribed, s.Topics...) },
+		OnUnsubscribe: func(s Subscription) { unsubscribed = append(unsubscribed, s.Topics...) },
+	}))
+
+	sub := h.Subscribe(1, "a")
+	h.NonBlockingSubscribe(1, "b")
+	h.With(Fields{"foo": "bar"}).Publish(Message{Name: "a"})
+	h.Unsubscribe(sub)
+	h.Close()
+
+	require.Equal(t, []string{"a"}, published)
+	require.Equal(t, []string{"a", "b"}, subscribed)
+	require.Equal(t, []string{"a", "b"}, unsubscribed)
+
+	unsubscribed = nil
+	closed := 0
+
+	h = New(WithHooks(Hooks{
+		OnUnsubscribe: func(s Subscription) {
+			unsubscribed = append(unsubscribed, s.Topics...)
+			closed++
+		},
+	}))
+
+	sub = h.Subscribe(1, "c", "d")
+	h.Close()
+
+	_, ok := <-sub.Receiver
+	require.False(t, ok)
+	require.Equal(t, 1, closed, "the hook must be called once for each subscription")
+	require.ElementsMatch(t, []string{"c", "d"}, unsubscribed)
+}
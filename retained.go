@@ -0,0 +1,246 @@
+package hub
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+type (
+	// store keeps the retained message and the history of each topic.
+	// The lock orders the stored messages with the new subscriptions, so every subscription receives
+	// a stored message exactly once, from the store or from the publish.
+	store struct {
+		mu       sync.RWMutex
+		seq      uint64
+		retained map[string]storedMessage
+		history  map[string][]storedMessage
+		limit    int
+		age      time.Duration
+		swept    time.Time
+	}
+
+	// storedMessage is a message kept by the store with the order and the time it was published.
+	storedMessage struct {
+		msg  Message
+		seq  uint64
+		time time.Time
+	}
+
+	// replaySubscriber delivers the stored messages before the messages published after the subscription.
+	// While the stored messages are delivered the new messages are kept in memory, up to the capacity of the
+	// subscriber like they were buffered by it. After that the publishers wait until the replay is done and
+	// the messages are sent to the subscriber, so they have the backpressure of the subscriber.
+	replaySubscriber struct {
+		Subscriber
+		replaying int32
+		mu        sync.Mutex
+		pending   []Message
+		limit     int
+		done      chan struct{}
+	}
+)
+
+// newStore returns an empty store keeping at most limit messages or the messages newer than age
+// in the history of each topic. If both are <= 0 the history is disabled.
+func newStore(limit int, age time.Duration) *store {
+	return &store{
+		retained: make(map[string]storedMessage),
+		history:  make(map[string][]storedMessage),
+		limit:    limit,
+		age:      age,
+	}
+}
+
+// PublishRetained will send an event to all the subscribers matching the event name and keep it as the
+// retained message of the topic, replacing the previous one.
+// New subscriptions receive the retained messages matching their topics before any other message.
+func (h *Hub) PublishRetained(m Message) {
+	m, err := h.prepare(m)
+	if err != nil {
+		h.publishError(err, m, []string{m.Topic()}, nil)
+		return
+	}
+
+	h.store.mu.Lock()
+	h.store.retained[m.Topic()] = h.store.record(m, h.now())
+	subs := mergeGroups(h.matcher.Lookup(m.Topic()))
+	h.store.mu.Unlock()
+
+	for _, sub := range subs {
+		sub.Set(m.clone())
+	}
+}
+
+// Retained returns a copy of the retained message of the given topic.
+func (h *Hub) Retained(topic string) (Message, bool) {
+	h.store.mu.RLock()
+	defer h.store.mu.RUnlock()
+
+	r, ok := h.store.retained[topic]
+
+	return r.msg.clone(), ok
+}
+
+// ClearRetained removes the retained message of the given topic.
+func (h *Hub) ClearRetained(topic string) {
+	h.store.mu.Lock()
+	defer h.store.mu.Unlock()
+
+	delete(h.store.retained, topic)
+}
+
+// matching returns the retained messages, and the history if asked, with a topic matching one of the given
+// topics in the order they were published. The topics are matched by the given matcher, so the stored
+// messages follow the same routing of the published ones.
+func (s *store) matching(m Matcher, topics []string, history bool, now time.Time) []Message {
+	if len(s.retained) == 0 && (!history || len(s.history) == 0) {
+		return nil
+	}
+
+	p := &probe{}
+	m.Subscribe(topics, p)
+
+	defer m.Unsubscribe(NewSubscription(topics, p))
+
+	found := make(map[uint64]storedMessage)
+
+	for topic, sm := range s.retained {
+		if p.matches(m, topic) {
+			found[sm.seq] = sm
+		}
+	}
+
+	if history {
+		for topic, sms := range s.history {
+			if !p.matches(m, topic) {
+				continue
+			}
+
+			for _, sm := range s.recent(sms, now) {
+				found[sm.seq] = sm
+			}
+		}
+	}
+
+	msgs := make([]storedMessage, 0, len(found))
+	for _, sm := range found {
+		msgs = append(msgs, sm)
+	}
+
+	sort.Slice(msgs, func(i, j int) bool { return msgs[i].seq < msgs[j].seq })
+
+	return messages(msgs)
+}
+
+// messages returns the messages kept inside the storedMessages.
+func messages(sms []storedMessage) []Message {
+	msgs := make([]Message, len(sms))
+	for i := range sms {
+		msgs[i] = sms[i].msg
+	}
+
+	return msgs
+}
+
+// probe is a Subscriber used only to check the topics matched by the topics of a new subscription.
+// It's not empty so each probe has its own address.
+type probe struct {
+	_ byte
+}
+
+func (*probe) Set(Message)        {}
+func (*probe) Ch() <-chan Message { return nil }
+func (*probe) Close()             {}
+
+// matches returns true if the probe is one of the subscribers of the topic.
+func (p *probe) matches(m Matcher, topic string) bool {
+	for _, sub := range m.Lookup(topic) {
+		if sub == p {
+			return true
+		}
+	}
+
+	return false
+}
+
+// newReplaySubscriber returns a replaySubscriber delivering the stored messages before the new ones.
+func newReplaySubscriber(sub Subscriber) *replaySubscriber {
+	return &replaySubscriber{Subscriber: sub, replaying: 1, limit: cap(sub.Ch()), done: make(chan struct{})}
+}
+
+// Set keeps the message if the stored messages are being delivered or send it to the subscriber.
+func (s *replaySubscriber) Set(msg Message) {
+	if s.hold(msg) {
+		return
+	}
+
+	<-s.done
+	s.Subscriber.Set(msg)
+}
+
+// SetContext keeps the message if the stored messages are being delivered or send it to the subscriber.
+// The messages kept are reported as delivered, like the messages buffered by the subscriber.
+func (s *replaySubscriber) SetContext(ctx context.Context, msg Message) delivery {
+	if s.hold(msg) {
+		return delivered
+	}
+
+	select {
+	case <-s.done:
+	case <-ctx.Done():
+		return timedOut
+	}
+
+	if cs, ok := s.Subscriber.(contextSubscriber); ok {
+		return cs.SetContext(ctx, msg)
+	}
+
+	s.Subscriber.Set(msg)
+
+	return delivered
+}
+
+// hold appends the message to the pending ones if the stored messages are being delivered and there are
+// less pending messages than the limit.
+func (s *replaySubscriber) hold(msg Message) bool {
+	if atomic.LoadInt32(&s.replaying) == 0 {
+		return false
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if atomic.LoadInt32(&s.replaying) == 0 || len(s.pending) >= s.limit {
+		return false
+	}
+
+	s.pending = append(s.pending, msg)
+
+	return true
+}
+
+// replay sends the given messages and the pending ones to the subscriber, stop holding new messages
+// and release the publishers waiting for it.
+func (s *replaySubscriber) replay(msgs []Message) {
+	for {
+		for _, msg := range msgs {
+			s.Subscriber.Set(msg.clone())
+		}
+
+		s.mu.Lock()
+		msgs, s.pending = s.pending, nil
+
+		if len(msgs) == 0 {
+			atomic.StoreInt32(&s.replaying, 0)
+			close(s.done)
+			s.mu.Unlock()
+
+			return
+		}
+
+		s.mu.Unlock()
+	}
+}
@@ -0,0 +1,158 @@
+package hub
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRetainedMessagesShouldBeSentToNewSubscriptions(t *testing.T) {
+	h := New()
+
+	defer h.Close()
+
+	h.PublishRetained(Message{Name: "a.b", Fields: Fields{"i": 1}})
+	h.PublishRetained(Message{Name: "a.c", Fields: Fields{"i": 2}})
+	h.PublishRetained(Message{Name: "b.c", Fields: Fields{"i": 3}})
+	h.PublishRetained(Message{Name: "a.b", Fields: Fields{"i": 4}})
+	h.Publish(Message{Name: "a.d", Fields: Fields{"i": 5}})
+
+	tests := map[string]struct {
+		topics   []string
+		expected []int
+	}{
+		"exact topic":       {topics: []string{"a.b"}, expected: []int{4}},
+		"wildcard":          {topics: []string{"a.*"}, expected: []int{2, 4}},
+		"multi wildcard":    {topics: []string{"#"}, expected: []int{2, 3, 4}},
+		"multiple topics":   {topics: []string{"b.*", "a.c"}, expected: []int{2, 3}},
+		"no retained match": {topics: []string{"a.d"}},
+	}
+
+	for name, tc := range tests {
+		tc := tc
+
+		t.Run(name, func(t *testing.T) {
+			sub := h.NonBlockingSubscribe(10, tc.topics...)
+			h.Publish(Message{Name: tc.topics[0], Fields: Fields{"i": 0}})
+
+			for _, n := range tc.expected {
+				msg := <-sub.Receiver
+				require.Equal(t, n, msg.Fields["i"])
+			}
+
+			msg := <-sub.Receiver
+			require.Equal(t, 0, msg.Fields["i"], "retained messages must be sent before the live ones")
+			h.Unsubscribe(sub)
+		})
+	}
+}
+
+func TestClearRetained(t *testing.T) {
+	h := New()
+
+	defer h.Close()
+
+	h.PublishRetained(Message{Name: "a", Body: []byte("1")})
+
+	msg, ok := h.Retained("a")
+	require.True(t, ok)
+	require.Equal(t, []byte("1"), msg.Body)
+
+	h.ClearRetained("a")
+
+	_, ok = h.Retained("a")
+	require.False(t, ok)
+
+	sub := h.NonBlockingSubscribe(10, "a")
+	h.Publish(Message{Name: "a", Body: []byte("2")})
+	require.Equal(t, []byte("2"), (<-sub.Receiver).Body)
+}
+
+func TestRetainedMessagesShouldNotBeDuplicatedOrLost(t *testing.T) {
+	const count = 1000
+
+	h := New()
+
+	defer h.Close()
+
+	var wg sync.WaitGroup
+
+	wg.Add(1)
+
+	go func() {
+		defer wg.Done()
+
+		for i := 1; i <= count; i++ {
+			h.PublishRetained(Message{Name: "a.b", Fields: Fields{"i": i}})
+		}
+	}()
+
+	received := make([][]int, 20)
+
+	for n := range received {
+		n := n
+		sub := h.Subscribe(count, "a.*")
+
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			for msg := range sub.Receiver {
+				i := msg.Fields["i"].(int)
+				received[n] = append(received[n], i)
+
+				if i == count {
+					return
+				}
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	for _, r := range received {
+		for i := 1; i < len(r); i++ {
+			require.Equal(t, r[i-1]+1, r[i], "after the first message all the messages must be received in order")
+		}
+	}
+}
+
+// exactMatcher matches only the topics equal to the subscribed ones.
+type exactMatcher struct {
+	*listMatcher
+}
+
+func (m exactMatcher) Lookup(topic string) []Subscriber {
+	subs := []Subscriber{}
+
+	for _, s := range m.Subscriptions() {
+		for _, t := range s.Topics {
+			if t == topic {
+				subs = append(subs, s.Subscriber())
+				break
+			}
+		}
+	}
+
+	return subs
+}
+
+func TestRetainedMessagesShouldUseTheHubMatcher(t *testing.T) {
+	h := New(WithMatcher(exactMatcher{newListMatcher()}))
+
+	defer h.Close()
+
+	h.PublishRetained(Message{Name: "a.b", Fields: Fields{"i": 1}})
+	h.PublishRetained(Message{Name: "a.*", Fields: Fields{"i": 2}})
+
+	sub := h.NonBlockingSubscribe(10, "a.*")
+	h.Publish(Message{Name: "a.b", Fields: Fields{"i": 3}})
+	h.Publish(Message{Name: "a.*", Fields: Fields{"i": 4}})
+
+	require.Equal(t, 2, (<-sub.Receiver).Fields["i"])
+	require.Equal(t, 4, (<-sub.Receiver).Fields["i"])
+	require.Empty(t, sub.Receiver)
+	require.Len(t, h.matcher.Subscriptions(), 1, "the probe must be removed from the matcher")
+}
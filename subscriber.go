@@ -1,11 +1,32 @@
 package hub
 
 import (
+	"context"
+	"fmt"
 	"sync"
+	"time"
+)
+
+// defaultCapacity is the capacity used by nonblocking subscribers when the capacity is not valid.
+const defaultCapacity = 10
+
+// The results of a delivery to one subscriber.
+const (
+	delivered delivery = iota
+	dropped
+	timedOut
 )
 
 type (
-	alertFunc func(missed int)
+	alertFunc   func(lost Message)
+	timeoutFunc func(lost Message, waited time.Duration)
+	delivery    int
+
+	// contextSubscriber is implemented by the subscribers able to respect the context and
+	// report the result of the delivery.
+	contextSubscriber interface {
+		SetContext(ctx context.Context, msg Message) delivery
+	}
 
 	nonBlockingSubscriber struct {
 		ch        chan Message
@@ -14,9 +35,46 @@ type (
 		mu        sync.RWMutex
 		closed    bool
 	}
+	// timeoutSubscriber blocks until the message is sent or the timeout is reached.
+	timeoutSubscriber struct {
+		ch        chan Message
+		timeout   time.Duration
+		alert     timeoutFunc
+		done      chan struct{}
+		onceClose sync.Once
+		mu        sync.RWMutex
+		closed    bool
+	}
+	// ringSubscriber works like a ring buffer, when the capacity is full the oldest message is evicted.
+	ringSubscriber struct {
+		ch        chan Message
+		alert     alertFunc
+		onceClose sync.Once
+		mu        sync.Mutex
+		closed    bool
+	}
+	// conflatingSubscriber keeps only the latest pending message for each key.
+	conflatingSubscriber struct {
+		ch        chan Message
+		key       func(Message) conflationKey
+		notify    chan struct{}
+		done      chan struct{}
+		onceClose sync.Once
+		mu        sync.Mutex
+		closed    bool
+		keys      []conflationKey
+		pending   map[conflationKey]Message
+	}
+
+	// conflationKey is the key used to coalesce the messages inside the conflatingSubscriber.
+	conflationKey struct {
+		field bool
+		value string
+	}
 	// blockingSubscriber uses an channel to receive events.
 	blockingSubscriber struct {
 		ch        chan Message
+		done      chan struct{}
 		onceClose sync.Once
 		mu        sync.RWMutex
 		closed    bool
@@ -28,7 +86,7 @@ type (
 // we will ignore the message and call the Alert function from the Alerter.
 func newNonBlockingSubscriber(cap int, alerter alertFunc) *nonBlockingSubscriber {
 	if cap <= 0 {
-		cap = 10
+		cap = defaultCapacity
 	}
 
 	return &nonBlockingSubscriber{
@@ -39,17 +97,28 @@ func newNonBlockingSubscriber(cap int, alerter alertFunc) *nonBlockingSubscriber
 
 // Set inserts the given Event into the diode.
 func (s *nonBlockingSubscriber) Set(msg Message) {
+	s.SetContext(context.Background(), msg)
+}
+
+// SetContext inserts the given Event into the diode and report if it was dropped.
+func (s *nonBlockingSubscriber) SetContext(ctx context.Context, msg Message) delivery {
 	s.mu.RLock()
-	defer s.mu.RUnlock()
 
 	if s.closed {
-		return
+		s.mu.RUnlock()
+		return dropped
 	}
 
 	select {
 	case s.ch <- msg:
+		s.mu.RUnlock()
+		return delivered
 	default:
-		s.alert(1)
+		// The alert is called without the lock because it can publish into this subscriber.
+		s.mu.RUnlock()
+		s.alert(msg)
+
+		return dropped
 	}
 }
 
@@ -69,6 +138,175 @@ func (s *nonBlockingSubscriber) Close() {
 	})
 }
 
+// newRingSubscriber returns a new ringSubscriber
+// this subscriber will never block when sending an message, if the capacity is full
+// the oldest message is removed from the channel and the Alert function is called.
+func newRingSubscriber(cap int, alerter alertFunc) *ringSubscriber {
+	if cap <= 0 {
+		cap = defaultCapacity
+	}
+
+	return &ringSubscriber{
+		ch:    make(chan Message, cap),
+		alert: alerter,
+	}
+}
+
+// Set inserts the given Event into the ring, evicting the oldest one if the ring is full.
+func (s *ringSubscriber) Set(msg Message) {
+	// The alert is called without the lock because it can publish into this subscriber.
+	for _, evicted := range s.insert(msg) {
+		s.alert(evicted)
+	}
+}
+
+// insert sends the message to the channel and returns the evicted messages.
+func (s *ringSubscriber) insert(msg Message) []Message {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return nil
+	}
+
+	var evicted []Message
+
+	for {
+		select {
+		case s.ch <- msg:
+			return evicted
+		default:
+		}
+
+		// The consumer can read concurrently so the eviction is optional.
+		select {
+		case m := <-s.ch:
+			evicted = append(evicted, m)
+		default:
+		}
+	}
+}
+
+// Ch return the channel used by subscriptions to consume messages.
+func (s *ringSubscriber) Ch() <-chan Message {
+	return s.ch
+}
+
+// Close will close the internal channel and stop receiving messages.
+func (s *ringSubscriber) Close() {
+	s.onceClose.Do(func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		s.closed = true
+		close(s.ch)
+	})
+}
+
+// newConflatingSubscriber returns a new conflatingSubscriber
+// this subscriber will never block when sending an message, pending messages are coalesced using the value
+// of the given field or the message topic if the field is empty or not present in the message.
+func newConflatingSubscriber(field string) *conflatingSubscriber {
+	s := &conflatingSubscriber{
+		ch:      make(chan Message),
+		notify:  make(chan struct{}, 1),
+		done:    make(chan struct{}),
+		pending: make(map[conflationKey]Message),
+		key: func(m Message) conflationKey {
+			if v, ok := m.Fields[field]; ok && field != "" {
+				return conflationKey{field: true, value: fmt.Sprint(v)}
+			}
+
+			return conflationKey{value: m.Topic()}
+		},
+	}
+
+	go s.run()
+
+	return s
+}
+
+// Set replaces the pending message with the same key or enqueue the message.
+func (s *conflatingSubscriber) Set(msg Message) {
+	s.mu.Lock()
+
+	if s.closed {
+		s.mu.Unlock()
+		return
+	}
+
+	k := s.key(msg)
+	if _, ok := s.pending[k]; !ok {
+		s.keys = append(s.keys, k)
+	}
+
+	s.pending[k] = msg
+	s.mu.Unlock()
+
+	select {
+	case s.notify <- struct{}{}:
+	default:
+	}
+}
+
+// Ch return the channel used by subscriptions to consume messages.
+func (s *conflatingSubscriber) Ch() <-chan Message {
+	return s.ch
+}
+
+// Close will stop receiving messages and close the internal channel, pending messages are discarded.
+func (s *conflatingSubscriber) Close() {
+	s.onceClose.Do(func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		s.closed = true
+		s.keys = nil
+		s.pending = nil
+		close(s.done)
+	})
+}
+
+// run sends the pending messages to the channel until the subscriber is closed.
+func (s *conflatingSubscriber) run() {
+	defer close(s.ch)
+
+	for {
+		msg, ok := s.next()
+		if !ok {
+			select {
+			case <-s.notify:
+				continue
+			case <-s.done:
+				return
+			}
+		}
+
+		select {
+		case s.ch <- msg:
+		case <-s.done:
+			return
+		}
+	}
+}
+
+// next removes and returns the oldest pending message.
+func (s *conflatingSubscriber) next() (Message, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(s.keys) == 0 {
+		return Message{}, false
+	}
+
+	k := s.keys[0]
+	s.keys = s.keys[1:]
+	msg := s.pending[k]
+	delete(s.pending, k)
+
+	return msg, true
+}
+
 // newBlockingSubscriber returns a blocking subscriber using chanels imternally.
 func newBlockingSubscriber(cap int) *blockingSubscriber {
 	if cap < 0 {
@@ -76,20 +314,39 @@ func newBlockingSubscriber(cap int) *blockingSubscriber {
 	}
 
 	return &blockingSubscriber{
-		ch: make(chan Message, cap),
+		ch:   make(chan Message, cap),
+		done: make(chan struct{}),
 	}
 }
 
 // Set will send the message using the channel.
 func (s *blockingSubscriber) Set(msg Message) {
+	s.SetContext(context.Background(), msg)
+}
+
+// SetContext will send the message using the channel until the context is done.
+func (s *blockingSubscriber) SetContext(ctx context.Context, msg Message) delivery {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
 	if s.closed {
-		return
+		return dropped
+	}
+
+	select {
+	case s.ch <- msg:
+		return delivered
+	default:
 	}
 
-	s.ch <- msg
+	select {
+	case s.ch <- msg:
+		return delivered
+	case <-ctx.Done():
+		return timedOut
+	case <-s.done:
+		return dropped
+	}
 }
 
 // Ch return the channel used by subscriptions to consume messages.
@@ -98,8 +355,91 @@ func (s *blockingSubscriber) Ch() <-chan Message {
 }
 
 // Close will close the internal channel and stop receiving messages.
+// Messages being sent are interrupted, so closing never waits for the consumer.
 func (s *blockingSubscriber) Close() {
 	s.onceClose.Do(func() {
+		close(s.done)
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		s.closed = true
+		close(s.ch)
+	})
+}
+
+// newTimeoutSubscriber returns a blocking subscriber which gives up after the given timeout.
+// When a message is dropped the Alert function is called with the time waited.
+func newTimeoutSubscriber(cap int, timeout time.Duration, alerter timeoutFunc) *timeoutSubscriber {
+	if cap < 0 {
+		cap = 0
+	}
+
+	return &timeoutSubscriber{
+		ch:      make(chan Message, cap),
+		timeout: timeout,
+		alert:   alerter,
+		done:    make(chan struct{}),
+	}
+}
+
+// Set will send the message using the channel, waiting at most the timeout.
+func (s *timeoutSubscriber) Set(msg Message) {
+	s.SetContext(context.Background(), msg)
+}
+
+// SetContext will send the message using the channel, waiting at most the timeout or until the context is done.
+func (s *timeoutSubscriber) SetContext(ctx context.Context, msg Message) delivery {
+	d, waited := s.send(ctx, msg)
+	if waited > 0 {
+		// The alert is called without the lock because it can publish into this subscriber.
+		s.alert(msg, waited)
+	}
+
+	return d
+}
+
+// send will send the message using the channel and returns the time waited if the timeout was reached.
+func (s *timeoutSubscriber) send(ctx context.Context, msg Message) (delivery, time.Duration) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.closed {
+		return dropped, 0
+	}
+
+	select {
+	case s.ch <- msg:
+		return delivered, 0
+	default:
+	}
+
+	start := time.Now()
+	timer := time.NewTimer(s.timeout)
+
+	defer timer.Stop()
+
+	select {
+	case s.ch <- msg:
+		return delivered, 0
+	case <-timer.C:
+		return timedOut, time.Since(start)
+	case <-ctx.Done():
+		return timedOut, 0
+	case <-s.done:
+		return dropped, 0
+	}
+}
+
+// Ch return the channel used by subscriptions to consume messages.
+func (s *timeoutSubscriber) Ch() <-chan Message {
+	return s.ch
+}
+
+// Close will close the internal channel and stop receiving messages.
+// Messages being sent are interrupted, so closing never waits for the consumer.
+func (s *timeoutSubscriber) Close() {
+	s.onceClose.Do(func() {
+		close(s.done)
 		s.mu.Lock()
 		defer s.mu.Unlock()
 
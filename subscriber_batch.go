@@ -0,0 +1,174 @@
+package hub
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// defaultBatchFlushTimeout is the time the last batch waits for the consumer when the subscriber is closed.
+const defaultBatchFlushTimeout = time.Second
+
+type (
+	// BatchSubscription represents a topic subscription receiving the messages in batches.
+	// The Receiver channel is nil, the messages are received using the Batches channel.
+	BatchSubscription struct {
+		Subscription
+		Batches <-chan []Message
+	}
+
+	// batchSubscriber groups the messages and send them when the batch is full or the latency is reached.
+	batchSubscriber struct {
+		in        chan Message
+		out       chan []Message
+		size      int
+		latency   time.Duration
+		flushWait time.Duration
+		done      chan struct{}
+		onceClose sync.Once
+		mu        sync.RWMutex
+		closed    bool
+	}
+)
+
+// newBatchSubscriber returns a blocking subscriber which delivers the messages in batches.
+// The batch is sent when it reaches the given size or when the first message waited the given latency.
+// If latency <= 0 the batches are sent only when they are full. When closed the last batch waits at most
+// flushWait for the consumer, or until it's received if flushWait <= 0.
+func newBatchSubscriber(size int, latency, flushWait time.Duration) *batchSubscriber {
+	if size <= 0 {
+		size = defaultCapacity
+	}
+
+	s := &batchSubscriber{
+		in:        make(chan Message),
+		out:       make(chan []Message),
+		size:      size,
+		latency:   latency,
+		flushWait: flushWait,
+		done:      make(chan struct{}),
+	}
+
+	go s.run()
+
+	return s
+}
+
+// Set will add the message into the current batch.
+func (s *batchSubscriber) Set(msg Message) {
+	s.SetContext(context.Background(), msg)
+}
+
+// SetContext will add the message into the current batch, waiting until the context is done.
+func (s *batchSubscriber) SetContext(ctx context.Context, msg Message) delivery {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.closed {
+		return dropped
+	}
+
+	select {
+	case s.in <- msg:
+		return delivered
+	default:
+	}
+
+	select {
+	case s.in <- msg:
+		return delivered
+	case <-ctx.Done():
+		return timedOut
+	case <-s.done:
+		return dropped
+	}
+}
+
+// Ch return a nil channel, the messages are consumed using the Batches channel.
+func (s *batchSubscriber) Ch() <-chan Message {
+	return nil
+}
+
+// Batches return the channel used by subscriptions to consume the batches.
+func (s *batchSubscriber) Batches() <-chan []Message {
+	return s.out
+}
+
+// Close will stop receiving messages, send the current batch and close the internal channel.
+// Messages being added are interrupted, so closing never waits for the consumer. The current batch is dropped
+// if the consumer doesn't receive it before the flush timeout, so the subscriber doesn't leak its goroutine.
+func (s *batchSubscriber) Close() {
+	s.onceClose.Do(func() {
+		close(s.done)
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		s.closed = true
+		close(s.in)
+	})
+}
+
+// run groups the received messages and sends the batches until the subscriber is closed.
+func (s *batchSubscriber) run() {
+	defer close(s.out)
+
+	var (
+		batch   []Message
+		timer   *time.Timer
+		timeout <-chan time.Time
+	)
+
+	for {
+		select {
+		case msg, ok := <-s.in:
+			if !ok {
+				s.flush(batch)
+
+				return
+			}
+
+			batch = append(batch, msg)
+			if len(batch) < s.size {
+				if len(batch) == 1 && s.latency > 0 {
+					timer = time.NewTimer(s.latency)
+					timeout = timer.C
+				}
+
+				continue
+			}
+
+			if timer != nil {
+				timer.Stop()
+			}
+		case <-timeout:
+		}
+
+		timeout = nil
+
+		select {
+		case s.out <- batch:
+			batch = nil
+		case <-s.done:
+		}
+	}
+}
+
+// flush sends the last batch, waiting at most the flush timeout for the consumer.
+func (s *batchSubscriber) flush(batch []Message) {
+	if len(batch) == 0 {
+		return
+	}
+
+	if s.flushWait <= 0 {
+		s.out <- batch
+		return
+	}
+
+	timer := time.NewTimer(s.flushWait)
+	defer timer.Stop()
+
+	select {
+	case s.out <- batch:
+	case <-timer.C:
+	}
+}
@@ -0,0 +1,186 @@
+package hub
+
+import (
+	"sync"
+	"unsafe"
+)
+
+// fieldOverhead is the estimated memory used by each field of a message besides the key.
+const fieldOverhead = 32
+
+type (
+	// QueueLimits are the high-water marks used by unbounded subscriptions.
+	// An alert is published when the queue length or size crosses one of them, zero values are ignored.
+	// The alert is published again only after the queue is drained below half of the limits.
+	QueueLimits struct {
+		Len  int
+		Size int
+	}
+
+	// QueueStats returns the current state of an unbounded subscription queue.
+	QueueStats interface {
+		// Len returns the number of messages waiting in the queue.
+		Len() int
+		// Size returns an estimate of the memory in bytes used by the messages waiting in the queue.
+		Size() int
+	}
+
+	watermarkFunc func(length, size int)
+
+	// unboundedSubscriber keeps the messages in an unbounded queue until consumed.
+	unboundedSubscriber struct {
+		ch        chan Message
+		limits    QueueLimits
+		warn      watermarkFunc
+		notify    chan struct{}
+		done      chan struct{}
+		onceClose sync.Once
+		mu        sync.Mutex
+		closed    bool
+		warned    bool
+		queue     []Message
+		size      int
+	}
+)
+
+// newUnboundedSubscriber returns a new unboundedSubscriber
+// this subscriber will never block or loose messages when sending an message, the Warn function is called
+// when the queue crosses one of the limits.
+func newUnboundedSubscriber(limits QueueLimits, warn watermarkFunc) *unboundedSubscriber {
+	s := &unboundedSubscriber{
+		ch:     make(chan Message),
+		limits: limits,
+		warn:   warn,
+		notify: make(chan struct{}, 1),
+		done:   make(chan struct{}),
+	}
+
+	go s.run()
+
+	return s
+}
+
+// Set enqueue the given message.
+func (s *unboundedSubscriber) Set(msg Message) {
+	s.mu.Lock()
+
+	if s.closed {
+		s.mu.Unlock()
+		return
+	}
+
+	s.queue = append(s.queue, msg)
+	s.size += messageSize(msg)
+
+	warn := !s.warned && s.above(1)
+	if warn {
+		s.warned = true
+	}
+
+	length, size := len(s.queue), s.size
+	s.mu.Unlock()
+
+	if warn {
+		s.warn(length, size)
+	}
+
+	select {
+	case s.notify <- struct{}{}:
+	default:
+	}
+}
+
+// Ch return the channel used by subscriptions to consume messages.
+func (s *unboundedSubscriber) Ch() <-chan Message {
+	return s.ch
+}
+
+// Close will stop receiving messages and close the internal channel, queued messages are discarded.
+func (s *unboundedSubscriber) Close() {
+	s.onceClose.Do(func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		s.closed = true
+		s.queue = nil
+		s.size = 0
+		close(s.done)
+	})
+}
+
+// Len returns the number of messages waiting in the queue.
+func (s *unboundedSubscriber) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return len(s.queue)
+}
+
+// Size returns an estimate of the memory in bytes used by the messages waiting in the queue.
+func (s *unboundedSubscriber) Size() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.size
+}
+
+// run sends the queued messages to the channel until the subscriber is closed.
+func (s *unboundedSubscriber) run() {
+	defer close(s.ch)
+
+	for {
+		msg, ok := s.next()
+		if !ok {
+			select {
+			case <-s.notify:
+				continue
+			case <-s.done:
+				return
+			}
+		}
+
+		select {
+		case s.ch <- msg:
+		case <-s.done:
+			return
+		}
+	}
+}
+
+// next removes and returns the oldest message in the queue.
+func (s *unboundedSubscriber) next() (Message, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(s.queue) == 0 {
+		return Message{}, false
+	}
+
+	msg := s.queue[0]
+	s.queue[0] = Message{}
+	s.queue = s.queue[1:]
+	s.size -= messageSize(msg)
+
+	if s.warned && !s.above(2) {
+		s.warned = false
+	}
+
+	return msg, true
+}
+
+// above returns true if the queue crosses one of the limits divided by the given factor.
+func (s *unboundedSubscriber) above(factor int) bool {
+	return (s.limits.Len > 0 && len(s.queue) > s.limits.Len/factor) ||
+		(s.limits.Size > 0 && s.size > s.limits.Size/factor)
+}
+
+// messageSize returns an estimate of the memory in bytes used by the message.
+func messageSize(m Message) int {
+	size := int(unsafe.Sizeof(m)) + len(m.Name) + len(m.Body) + len(m.ContentType) +
+		len(m.ID) + len(m.Source) + len(m.CorrelationID) + len(m.CausationID)
+	for k := range m.Fields {
+		size += len(k) + fieldOverhead
+	}
+
+	return size
+}
@@ -0,0 +1,96 @@
+package hub
+
+import (
+	"context"
+	"reflect"
+)
+
+// ValueField is the Fields key used by Topic to send the values.
+const ValueField = "value"
+
+// Topic is a typed view of one topic of the Hub.
+// The values are published inside the ValueField of the message, so they are routed like any other message
+// and untyped subscribers can receive them too.
+type Topic[T any] struct {
+	hub  *Hub
+	name string
+}
+
+// NewTopic returns a Topic publishing and receiving values of type T on the given topic of the hub.
+// The name can contain wildcards to receive the values of many topics, but publishing on it only reaches
+// the subscribers matching the name itself.
+func NewTopic[T any](h *Hub, name string) Topic[T] {
+	return Topic[T]{hub: h, name: name}
+}
+
+// Name returns the topic name.
+func (t Topic[T]) Name() string {
+	return t.name
+}
+
+// Publish sends the value to all the subscribers matching the topic.
+// Blocking subscribers stop waiting when the context is done and in this case the context error is returned.
+func (t Topic[T]) Publish(ctx context.Context, v T) error {
+	_, err := t.hub.PublishContext(ctx, Message{Name: t.name, Fields: Fields{ValueField: v}})
+
+	return err
+}
+
+// Subscribe create a blocking subscription to receive the values published on the topic.
+// The subscription is removed and the channel closed when the given context is done. With a context which
+// is never done, like context.Background(), the subscription is only removed by Hub.Close.
+// Messages without a value of type T are converted like Fields.Decode using the ValueField. Without the
+// ValueField the Body is decoded with the Codec of the message ContentType, or all the Fields are decoded if
+// the message has no Body. The messages which can't be converted are published on the error topic, except the
+// messages of the error and alert topics which are dropped quietly to avoid an endless loop of errors.
+func (t Topic[T]) Subscribe(ctx context.Context, cap int) <-chan T {
+	sub := t.hub.SubscribeContext(ctx, cap, t.name)
+	ch := make(chan T)
+
+	go func() {
+		defer close(ch)
+
+		for msg := range sub.Receiver {
+			v, err := t.decode(msg)
+			if err != nil {
+				if msg.Name != t.hub.errorTopic && msg.Name != t.hub.alertTopic {
+					t.hub.queueError(err, msg, sub.Topics, sub.subscriber)
+				}
+
+				continue
+			}
+
+			select {
+			case ch <- v:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return ch
+}
+
+// decode returns the value of the message.
+func (t Topic[T]) decode(msg Message) (T, error) {
+	var v T
+
+	raw, err := msg.field(ValueField)
+	if err != nil {
+		if len(msg.Body) > 0 && msg.ContentType != "" {
+			return v, msg.DecodeBody(&v)
+		}
+
+		if reflect.TypeOf(&v).Elem().Kind() != reflect.Struct {
+			return v, err
+		}
+
+		return v, msg.Fields.Decode(&v)
+	}
+
+	if typed, ok := raw.(T); ok {
+		return typed, nil
+	}
+
+	return v, fieldError(ValueField, decode(reflect.ValueOf(&v).Elem(), raw))
+}
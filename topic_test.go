@@ -0,0 +1,109 @@
+package hub
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type userCreated struct {
+	ID   int    `hub:"id"`
+	Name string `hub:"name"`
+}
+
+func TestTopicShouldPublishAndReceiveTypedValues(t *testing.T) {
+	h := New()
+
+	defer h.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	topic := NewTopic[userCreated](h, "user.created")
+	values := topic.Subscribe(ctx, 10)
+	untyped := h.Subscribe(10, "user.*")
+
+	require.Equal(t, "user.created", topic.Name())
+	require.NoError(t, topic.Publish(ctx, userCreated{ID: 1, Name: "alice"}))
+	require.Equal(t, userCreated{ID: 1, Name: "alice"}, <-values)
+
+	msg := <-untyped.Receiver
+	require.Equal(t, "user.created", msg.Name)
+	require.Equal(t, userCreated{ID: 1, Name: "alice"}, msg.Fields[ValueField])
+
+	h.Publish(Message{Name: "user.created", Fields: Fields{"id": "2", "name": "bob"}})
+	require.Equal(t, userCreated{ID: 2, Name: "bob"}, <-values)
+
+	cancel()
+
+	_, ok := <-values
+	require.False(t, ok, "the channel must be closed when the context is done")
+}
+
+func TestTopicShouldConvertUntypedValues(t *testing.T) {
+	h := New()
+	errs := h.Subscribe(10, ErrorTopic)
+
+	defer h.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	topic := NewTopic[int64](h, "counter.*")
+	values := topic.Subscribe(ctx, 10)
+
+	h.Publish(Message{Name: "counter.a", Fields: Fields{ValueField: 1}})
+	h.Publish(Message{Name: "counter.b", Fields: Fields{ValueField: "a"}})
+	h.Publish(Message{Name: "counter.c"})
+	h.Publish(Message{Name: "counter.d", Fields: Fields{ValueField: "4"}})
+
+	require.Equal(t, int64(1), <-values)
+	require.Equal(t, int64(4), <-values)
+
+	for _, expected := range []error{ErrFieldType, ErrFieldNotFound} {
+		msg := <-errs.Receiver
+		err, _ := msg.Fields["error"].(error)
+		require.True(t, errors.Is(err, expected), "unexpected error: %v", err)
+		require.Equal(t, []string{"counter.*"}, msg.Fields["topic"])
+	}
+}
+
+func TestTopicPublishShouldReturnTheContextError(t *testing.T) {
+	h := New()
+
+	defer h.Close()
+
+	topic := NewTopic[string](h, "a")
+	h.Subscribe(0, "a")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	require.Equal(t, context.DeadlineExceeded, topic.Publish(ctx, "value"))
+}
+
+func TestTopicShouldNotLoopOnItsOwnErrors(t *testing.T) {
+	h := New()
+	errs := h.NonBlockingSubscribe(10, ErrorTopic)
+
+	defer h.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	values := NewTopic[int](h, "#").Subscribe(ctx, 10)
+
+	h.Publish(Message{Name: "a"})
+	h.Publish(Message{Name: AlertTopic})
+	h.Publish(Message{Name: "b", Fields: Fields{ValueField: 1}})
+	require.Equal(t, 1, <-values)
+
+	msg := <-errs.Receiver
+	require.Equal(t, "a", msg.Fields["message"].(Message).Name)
+
+	time.Sleep(10 * time.Millisecond)
+	require.Empty(t, errs.Receiver, "the errors about the error and alert topics must be dropped")
+}
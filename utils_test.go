@@ -18,8 +18,10 @@ package hub
 import (
 	"math/rand"
 	"strconv"
+	"strings"
 	"sync"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -30,9 +32,9 @@ func (d discardSubscriber) Set(msg Message)    {}
 func (d discardSubscriber) Ch() <-chan Message { return make(chan Message) }
 func (d discardSubscriber) Close()             {}
 
-var result []subscriber
+var result []Subscriber
 
-func benchmarkMatcher(b *testing.B, numThreads int, m matcher, doSubs func(n int) bool) {
+func benchmarkMatcher(b *testing.B, numThreads int, m Matcher, doSubs func(n int) bool) {
 	numItems := 1000
 	itemsToInsert := generateTopics(numThreads, numItems)
 	sub := discardSubscriber(0)
@@ -47,7 +49,7 @@ func benchmarkMatcher(b *testing.B, numThreads int, m matcher, doSubs func(n int
 
 		for j := 0; j < numThreads; j++ {
 			go func(j int) {
-				var r []subscriber
+				var r []Subscriber
 
 				for n, key := range itemsToInsert[j] {
 					if doSubs(n) {
@@ -75,7 +77,7 @@ func percentual9010(n int) bool {
 	return n%10 == 0
 }
 
-func assertEqual(assert *assert.Assertions, expected, actual []subscriber) {
+func assertEqual(assert *assert.Assertions, expected, actual []Subscriber) {
 	assert.Len(actual, len(expected))
 
 	for _, sub := range expected {
@@ -100,7 +102,7 @@ func generateTopics(numThreads, numItems int) [][]string {
 	return itemsToInsert
 }
 
-func populateMatcher(m matcher, topicSize int) {
+func populateMatcher(m Matcher, topicSize int) {
 	num := 1000
 
 	for i := 0; i < num; i++ {
@@ -114,3 +116,95 @@ func populateMatcher(m matcher, topicSize int) {
 		m.Subscribe([]string{topic}, discardSubscriber(0))
 	}
 }
+
+// listMatcher is a naive Matcher which checks every subscription on each lookup.
+type listMatcher struct {
+	mu   sync.RWMutex
+	subs []Subscription
+}
+
+func newListMatcher() *listMatcher {
+	return &listMatcher{}
+}
+
+func (l *listMatcher) Subscribe(topics []string, sub Subscriber) Subscription {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	s := NewSubscription(topics, sub)
+	l.subs = append(l.subs, s)
+
+	return s
+}
+
+func (l *listMatcher) Unsubscribe(sub Subscription) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for i, s := range l.subs {
+		if s.Subscriber() == sub.Subscriber() {
+			l.subs = append(l.subs[:i], l.subs[i+1:]...)
+			return
+		}
+	}
+}
+
+func (l *listMatcher) Lookup(topic string) []Subscriber {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	words := strings.Split(topic, delimiter)
+	subs := []Subscriber{}
+
+	for _, s := range l.subs {
+		for _, t := range s.Topics {
+			if matchWords(strings.Split(t, delimiter), words) {
+				subs = append(subs, s.Subscriber())
+				break
+			}
+		}
+	}
+
+	return subs
+}
+
+func (l *listMatcher) Subscriptions() []Subscription {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	return append([]Subscription{}, l.subs...)
+}
+
+func matchWords(pattern, words []string) bool {
+	if len(pattern) == 0 {
+		return len(words) == 0
+	}
+
+	if pattern[0] == multiWildcard {
+		for n := 0; n <= len(words); n++ {
+			if matchWords(pattern[1:], words[n:]) {
+				return true
+			}
+		}
+
+		return false
+	}
+
+	if len(words) == 0 || (pattern[0] != wildcard && pattern[0] != words[0]) {
+		return false
+	}
+
+	return matchWords(pattern[1:], words[1:])
+}
+
+// withoutMetadata returns the messages without the metadata set by the hub on publish.
+func withoutMetadata(msgs ...Message) []Message {
+	clean := make([]Message, len(msgs))
+
+	for i, m := range msgs {
+		m.ID, m.Timestamp, m.Source, m.CorrelationID, m.CausationID = "", time.Time{}, "", "", ""
+		clean[i] = m
+	}
+
+	return clean
+}